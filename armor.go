@@ -0,0 +1,200 @@
+package cford32
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"strconv"
+)
+
+// The armored format wraps binary data in a self-describing, line-based text
+// block, which is resistant to transcription errors. For example:
+//
+//	BEGIN CFORD32 v1 NOTE 66
+//	AHVP2WS0C9S6JV3CD5KJR831DSJ20X38CMG76V39EHM7J83MDXV6AWS0CHMP8837T
+//	F5S6A831DSJ20SV9DNH6RS90D5Q20X38CMG7ERB2CMH
+//	END CFORD32 29DFCNR
+//
+// The header line contains the version of the format, the type of the data
+// and its length in bytes. Each body line contains up to 64 characters of the
+// encoded data, followed by a check symbol computed on the line. The footer
+// contains the encoding of the CRC-32 (IEEE) of the data.
+const (
+	armorBegin     = "BEGIN CFORD32"
+	armorEnd       = "END CFORD32"
+	armorVersion   = "v1"
+	armorLineWidth = 64
+)
+
+// lineCheck returns the check symbol of a body line. Each symbol's value is
+// weighted by its position in the line, so that, aside from substitutions,
+// transpositions of adjacent symbols are detected as well.
+func lineCheck(line []byte) byte {
+	sum := 0
+	for i, c := range line {
+		sum += int(decTable[c]) * (i%36 + 1)
+	}
+	return checkTable[sum%37]
+}
+
+// Errors wrapped in an [ArmorError], describing what is wrong with the line.
+var (
+	ErrArmorHeader   = errors.New("invalid armor header")
+	ErrArmorVersion  = errors.New("unsupported armor version")
+	ErrArmorFooter   = errors.New("missing armor footer")
+	ErrArmorLength   = errors.New("data length mismatch")
+	ErrArmorChecksum = errors.New("data checksum mismatch")
+)
+
+// ArmorError is returned by [DecodeArmor] when the armored data is invalid.
+// Line is the 1-based number of the line of the input containing the error;
+// Err is one of the ErrArmor errors, or a [DecodeError] for an invalid
+// character or check symbol in the line, whose offset is counted after
+// removing any leading whitespace.
+type ArmorError struct {
+	Line int
+	Err  error
+}
+
+func (e *ArmorError) Error() string {
+	return "cford32: armor line " + strconv.Itoa(e.Line) + ": " + e.Err.Error()
+}
+
+func (e *ArmorError) Unwrap() error { return e.Err }
+
+// AppendArmor appends the armored encoding of data to dst, using typ as the
+// type in the header, and returns the extended buffer.
+//
+// typ must be a non-empty string of printable ASCII characters without
+// spaces, such as "KEY"; AppendArmor panics otherwise.
+func AppendArmor(dst []byte, typ string, data []byte) []byte {
+	if !validArmorType([]byte(typ)) {
+		panic("cford32: invalid armor type " + strconv.Quote(typ))
+	}
+	dst = append(dst, armorBegin+" "+armorVersion+" "...)
+	dst = append(dst, typ...)
+	dst = append(dst, ' ')
+	dst = strconv.AppendInt(dst, int64(len(data)), 10)
+	dst = append(dst, '\n')
+
+	body := AppendEncode(nil, data)
+	for len(body) > 0 {
+		line := body[:min(armorLineWidth, len(body))]
+		body = body[len(line):]
+		dst = append(dst, line...)
+		dst = append(dst, lineCheck(line), '\n')
+	}
+
+	dst = append(dst, armorEnd+" "...)
+	dst = AppendEncode(dst, binary.BigEndian.AppendUint32(nil, crc32.ChecksumIEEE(data)))
+	return append(dst, '\n')
+}
+
+// EncodeArmor returns the armored encoding of data, as described in
+// [AppendArmor].
+func EncodeArmor(typ string, data []byte) string {
+	return string(AppendArmor(nil, typ, data))
+}
+
+func validArmorType(typ []byte) bool {
+	if len(typ) == 0 {
+		return false
+	}
+	for _, c := range typ {
+		if c <= ' ' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// DecodeArmor decodes the first armored block found in src, returning the
+// type given in its header and the decoded data. Any text before the header
+// line or after the footer line is ignored, as is whitespace surrounding each
+// line. Like the rest of the encoding, the armored block is case-insensitive.
+//
+// Each body line is verified with its check symbol, and the decoded data with
+// the length in the header and the checksum in the footer. If any of these
+// fail, an [*ArmorError] is returned, indicating the line containing the
+// error.
+func DecodeArmor(src []byte) (typ string, data []byte, err error) {
+	lines := bytes.Split(src, []byte{'\n'})
+	for i := range lines {
+		lines[i] = bytes.TrimSpace(lines[i])
+	}
+
+	// Find the header.
+	start := 0
+	for start < len(lines) && !hasPrefixFold(lines[start], armorBegin+" ") {
+		start++
+	}
+	if start == len(lines) {
+		return "", nil, &ArmorError{Line: 1, Err: ErrArmorHeader}
+	}
+	header := bytes.Fields(lines[start][len(armorBegin):])
+	if len(header) != 3 || !validArmorType(header[1]) {
+		return "", nil, &ArmorError{Line: start + 1, Err: ErrArmorHeader}
+	}
+	if !bytes.EqualFold(header[0], []byte(armorVersion)) {
+		return "", nil, &ArmorError{Line: start + 1, Err: ErrArmorVersion}
+	}
+	length, err := strconv.Atoi(string(header[2]))
+	if err != nil || length < 0 || EncodedLen(length) < 0 {
+		return "", nil, &ArmorError{Line: start + 1, Err: ErrArmorHeader}
+	}
+	typ = string(header[1])
+
+	// Verify and collect the body lines. All the lines but the last one are
+	// full, so a missing or repeated line is reported on the first line
+	// which does not match the length in the header.
+	want := EncodedLen(length)
+	var body []byte
+	i := start + 1
+	for ; i < len(lines) && !hasPrefixFold(lines[i], armorEnd); i++ {
+		line := lines[i]
+		if len(line) == 0 {
+			continue
+		}
+		content := line[:len(line)-1]
+		for j, c := range content {
+			if decTable[c] == 0xFF {
+				return "", nil, &ArmorError{Line: i + 1, Err: DecodeError{Offset: int64(j), Char: c, Err: ErrInvalidChar}}
+			}
+		}
+		if check := line[len(line)-1]; checkValue(check) != checkValue(lineCheck(content)) {
+			return "", nil, &ArmorError{Line: i + 1, Err: DecodeError{Offset: int64(len(content)), Char: check, Err: ErrCheckSymbol}}
+		}
+		if n := len(body) + len(content); n > want || n < want && len(content) != armorLineWidth {
+			return "", nil, &ArmorError{Line: i + 1, Err: ErrArmorLength}
+		}
+		body = append(body, content...)
+	}
+	if i == len(lines) {
+		return "", nil, &ArmorError{Line: i, Err: ErrArmorFooter}
+	}
+	if len(body) != want {
+		return "", nil, &ArmorError{Line: i + 1, Err: ErrArmorLength}
+	}
+	// The body lines have been validated, so decoding can't fail.
+	data = make([]byte, DecodedLen(len(body)))
+	n, _ := decode(data, body)
+	data = data[:n]
+
+	footer := bytes.Fields(lines[i][len(armorEnd):])
+	var sum [4]byte
+	if len(footer) != 1 || len(footer[0]) != EncodedLen(len(sum)) {
+		return "", nil, &ArmorError{Line: i + 1, Err: ErrArmorFooter}
+	}
+	if _, err := decode(sum[:], footer[0]); err != nil {
+		return "", nil, &ArmorError{Line: i + 1, Err: err}
+	}
+	if binary.BigEndian.Uint32(sum[:]) != crc32.ChecksumIEEE(data) {
+		return "", nil, &ArmorError{Line: i + 1, Err: ErrArmorChecksum}
+	}
+	return typ, data, nil
+}
+
+func hasPrefixFold(b []byte, prefix string) bool {
+	return len(b) >= len(prefix) && bytes.EqualFold(b[:len(prefix)], []byte(prefix))
+}
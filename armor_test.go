@@ -0,0 +1,85 @@
+package cford32
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const armorExample = `BEGIN CFORD32 v1 NOTE 66
+AHVP2WS0C9S6JV3CD5KJR831DSJ20X38CMG76V39EHM7J83MDXV6AWS0CHMP8837T
+F5S6A831DSJ20SV9DNH6RS90D5Q20X38CMG7ERB2CMH
+END CFORD32 29DFCNR
+`
+
+const armorExampleData = "Twas brillig, and the slithy toves did gyre and gimble in the wabe"
+
+func TestArmorRoundtrip(t *testing.T) {
+	got := EncodeArmor("NOTE", []byte(armorExampleData))
+	testEqual(t, "EncodeArmor(%q) = %q, want %q", armorExampleData, got, armorExample)
+
+	for _, p := range append(pairs, bigtest, testpair{decoded: strings.Repeat(armorExampleData, 10)}) {
+		armored := EncodeArmor("TEST", []byte(p.decoded))
+		typ, data, err := DecodeArmor([]byte(armored))
+		testEqual(t, "DecodeArmor(%q) gave error %v, want %v", armored, err, error(nil))
+		testEqual(t, "DecodeArmor(%q) gave type %q, want %q", armored, typ, "TEST")
+		testEqual(t, "DecodeArmor(%q) = %q, want %q", armored, string(data), p.decoded)
+	}
+}
+
+func TestDecodeArmor(t *testing.T) {
+	// Surrounding text, whitespace, CRLF line endings and lowercase are all
+	// accepted.
+	input := "Here's the note:\r\n\r\n  " +
+		strings.ReplaceAll(strings.ToLower(armorExample), "\n", "\r\n") +
+		"\r\nThanks!"
+	typ, data, err := DecodeArmor([]byte(input))
+	testEqual(t, "DecodeArmor gave error %v, want %v", err, error(nil))
+	testEqual(t, "DecodeArmor gave type %q, want %q", typ, "note")
+	testEqual(t, "DecodeArmor = %q, want %q", string(data), armorExampleData)
+}
+
+func TestDecodeArmorErrors(t *testing.T) {
+	lines := strings.Split(armorExample, "\n")
+	replace := func(line int, s string) string {
+		l := append([]string{}, lines...)
+		l[line] = s
+		return strings.Join(l, "\n")
+	}
+	testCases := []struct {
+		name  string
+		input string
+		line  int
+		err   error
+	}{
+		{"no header", "hello world", 1, ErrArmorHeader},
+		{"bad header", replace(0, "BEGIN CFORD32 v1 NOTE"), 1, ErrArmorHeader},
+		{"bad length", replace(0, "BEGIN CFORD32 v1 NOTE -1"), 1, ErrArmorHeader},
+		{"version", replace(0, "BEGIN CFORD32 v2 NOTE 66"), 1, ErrArmorVersion},
+		{"wrong length", replace(0, "BEGIN CFORD32 v1 NOTE 65"), 3, ErrArmorLength},
+		{"excluded char", replace(1, strings.Replace(lines[1], "AHVP", "AHUP", 1)), 2, CorruptInputError(2)},
+		{"typo", replace(2, strings.Replace(lines[2], "F5S6", "F556", 1)), 3, ErrCheckSymbol},
+		{"invalid char", replace(2, strings.Replace(lines[2], "F5S6", "F5 6S", 1)), 3, CorruptInputError(2)},
+		{"transposition", replace(1, strings.Replace(lines[1], "AHVP", "AHPV", 1)), 2, ErrCheckSymbol},
+		{"check symbol", replace(2, lines[2][:len(lines[2])-1]+"J"), 3, ErrCheckSymbol},
+		{"missing line", replace(1, ""), 3, ErrArmorLength},
+		{"missing last line", replace(2, ""), 4, ErrArmorLength},
+		{"repeated line", replace(2, lines[1]+"\n"+lines[2]), 3, ErrArmorLength},
+		{"no footer", strings.Join(lines[:3], "\n"), 3, ErrArmorFooter},
+		{"bad footer", replace(3, "END CFORD32"), 4, ErrArmorFooter},
+		{"bad checksum", replace(3, "END CFORD32 29DFCPR"), 4, ErrArmorChecksum},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := DecodeArmor([]byte(tc.input))
+			var aerr *ArmorError
+			if !errors.As(err, &aerr) {
+				t.Fatalf("expected ArmorError, got %v", err)
+			}
+			testEqual(t, "error line = %d, want %d", aerr.Line, tc.line)
+			if !errors.Is(err, tc.err) {
+				t.Errorf("error = %v, want %v", err, tc.err)
+			}
+		})
+	}
+}
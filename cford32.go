@@ -15,7 +15,11 @@
 //
 // This is slightly different from a simple difference in encoding table from
 // the Go's stdlib `encoding/base32`, as when decoding the characters i I l L are
-// parsed as 1, and o O is parsed as 0.
+// parsed as 1, and o O is parsed as 0. Newlines are ignored when decoding.
+// As allowed by the specification, hyphens may be used to split the encoded
+// data into groups: they can be removed before decoding using
+// [AppendStripSeparators], or ignored by the stream decoders using
+// [DecoderOptions].
 //
 // This package additionally provides ways to encode uint64's efficiently,
 // as well as efficient encoding to a lowercase variation of the encoding.
@@ -54,6 +58,7 @@ import (
 	"io"
 	"slices"
 	"strconv"
+	"strings"
 )
 
 const (
@@ -80,11 +85,11 @@ const (
 		"\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff"
 )
 
-// CorruptInputError is returned by parsing functions when an invalid character
-// in the input is found. The integer value represents the byte index where
-// the error occurred.
+// CorruptInputError represents invalid input found by the parsing functions.
+// The integer value represents the byte index where the error occurred.
 //
-// This is typically because the given character does not exist in the encoding.
+// To find out the reason for the error, use the detailed variants of the
+// parsing functions, such as [Uint64Detailed], which return a [DecodeError].
 type CorruptInputError int64
 
 func (e CorruptInputError) Error() string {
@@ -100,15 +105,25 @@ func (e CorruptInputError) Error() string {
 //   - If the first character is 'g' <= c <= 'z',  then the passed value is
 //     assumed encoded in the full encoding, and must be 13 characters long.
 //
-// If any of these requirements fail, a CorruptInputError will be returned.
+// If any of these requirements fail, a [CorruptInputError] will be returned.
 func Uint64(b []byte) (uint64, error) {
+	v, err := Uint64Detailed(b)
+	return v, corruptInput(err)
+}
+
+// Uint64Detailed is like [Uint64], but it returns a [DecodeError] describing
+// the reason for the error: [ErrInvalidChar] or [ErrInvalidLength]. Length
+// errors are reported at offset 0.
+func Uint64Detailed(b []byte) (uint64, error) {
 	if len(b) == 0 {
-		return 0, CorruptInputError(0)
+		return 0, DecodeError{Offset: 0, Err: ErrInvalidLength}
 	}
 	b0 := decTable[b[0]]
 	switch {
+	case b0 == 0xFF:
+		return 0, DecodeError{Offset: 0, Char: b[0], Err: ErrInvalidChar}
 	default:
-		return 0, CorruptInputError(0)
+		return 0, DecodeError{Offset: 0, Err: ErrInvalidLength}
 	case len(b) == 7 && b0 < 16:
 		decVals := [7]byte{
 			decTable[b[0]],
@@ -121,7 +136,7 @@ func Uint64(b []byte) (uint64, error) {
 		}
 		for idx, v := range decVals {
 			if v >= 32 {
-				return 0, CorruptInputError(idx)
+				return 0, DecodeError{Offset: int64(idx), Char: b[idx], Err: ErrInvalidChar}
 			}
 		}
 
@@ -151,7 +166,7 @@ func Uint64(b []byte) (uint64, error) {
 		}
 		for idx, v := range decVals {
 			if v >= 32 {
-				return 0, CorruptInputError(idx)
+				return 0, DecodeError{Offset: int64(idx), Char: b[idx], Err: ErrInvalidChar}
 			}
 		}
 
@@ -273,71 +288,28 @@ func EncodedLen(n int) int {
 // writing [EncodedLen](len(src)) bytes to dst.
 //
 // The encoding does not contain any padding, unlike Go's base32.
+// Encode panics if dst is too short; use [TryEncode] to check its length
+// instead.
 func Encode(dst, src []byte) {
-	// Copied from encoding/base32/base32.go (go1.22)
-	if len(src) == 0 {
-		return
-	}
-
-	di, si := 0, 0
-	n := (len(src) / 5) * 5
-	for si < n {
-		// Combining two 32 bit loads allows the same code to be used
-		// for 32 and 64 bit platforms.
-		hi := uint32(src[si+0])<<24 | uint32(src[si+1])<<16 | uint32(src[si+2])<<8 | uint32(src[si+3])
-		lo := hi<<8 | uint32(src[si+4])
-
-		dst[di+0] = encTable[(hi>>27)&0x1F]
-		dst[di+1] = encTable[(hi>>22)&0x1F]
-		dst[di+2] = encTable[(hi>>17)&0x1F]
-		dst[di+3] = encTable[(hi>>12)&0x1F]
-		dst[di+4] = encTable[(hi>>7)&0x1F]
-		dst[di+5] = encTable[(hi>>2)&0x1F]
-		dst[di+6] = encTable[(lo>>5)&0x1F]
-		dst[di+7] = encTable[(lo)&0x1F]
-
-		si += 5
-		di += 8
-	}
-
-	// Add the remaining small block
-	remain := len(src) - si
-	if remain == 0 {
-		return
-	}
-
-	// Encode the remaining bytes in reverse order.
-	val := uint32(0)
-	switch remain {
-	case 4:
-		val |= uint32(src[si+3])
-		dst[di+6] = encTable[val<<3&0x1F]
-		dst[di+5] = encTable[val>>2&0x1F]
-		fallthrough
-	case 3:
-		val |= uint32(src[si+2]) << 8
-		dst[di+4] = encTable[val>>7&0x1F]
-		fallthrough
-	case 2:
-		val |= uint32(src[si+1]) << 16
-		dst[di+3] = encTable[val>>12&0x1F]
-		dst[di+2] = encTable[val>>17&0x1F]
-		fallthrough
-	case 1:
-		val |= uint32(src[si+0]) << 24
-		dst[di+1] = encTable[val>>22&0x1F]
-		dst[di+0] = encTable[val>>27&0x1F]
-	}
+	n := encodeSWAR(dst, src, false)
+	encodeScalar(dst[EncodedLen(n):], src[n:], encTable)
 }
 
 // EncodeLower is like [Encode], but uses the lowercase variation of the
 // encoding.
 func EncodeLower(dst, src []byte) {
+	n := encodeSWAR(dst, src, true)
+	encodeScalar(dst[EncodedLen(n):], src[n:], encTableLower)
+}
+
+// encodeScalar encodes src using table, one 5-byte block at a time.
+func encodeScalar(dst, src []byte, table string) {
 	// Copied from encoding/base32/base32.go (go1.22)
 	if len(src) == 0 {
 		return
 	}
 
+	_ = table[31] // bounds check hint to compiler
 	di, si := 0, 0
 	n := (len(src) / 5) * 5
 	for si < n {
@@ -346,14 +318,14 @@ func EncodeLower(dst, src []byte) {
 		hi := uint32(src[si+0])<<24 | uint32(src[si+1])<<16 | uint32(src[si+2])<<8 | uint32(src[si+3])
 		lo := hi<<8 | uint32(src[si+4])
 
-		dst[di+0] = encTableLower[(hi>>27)&0x1F]
-		dst[di+1] = encTableLower[(hi>>22)&0x1F]
-		dst[di+2] = encTableLower[(hi>>17)&0x1F]
-		dst[di+3] = encTableLower[(hi>>12)&0x1F]
-		dst[di+4] = encTableLower[(hi>>7)&0x1F]
-		dst[di+5] = encTableLower[(hi>>2)&0x1F]
-		dst[di+6] = encTableLower[(lo>>5)&0x1F]
-		dst[di+7] = encTableLower[(lo)&0x1F]
+		dst[di+0] = table[(hi>>27)&0x1F]
+		dst[di+1] = table[(hi>>22)&0x1F]
+		dst[di+2] = table[(hi>>17)&0x1F]
+		dst[di+3] = table[(hi>>12)&0x1F]
+		dst[di+4] = table[(hi>>7)&0x1F]
+		dst[di+5] = table[(hi>>2)&0x1F]
+		dst[di+6] = table[(lo>>5)&0x1F]
+		dst[di+7] = table[(lo)&0x1F]
 
 		si += 5
 		di += 8
@@ -370,23 +342,46 @@ func EncodeLower(dst, src []byte) {
 	switch remain {
 	case 4:
 		val |= uint32(src[si+3])
-		dst[di+6] = encTableLower[val<<3&0x1F]
-		dst[di+5] = encTableLower[val>>2&0x1F]
+		dst[di+6] = table[val<<3&0x1F]
+		dst[di+5] = table[val>>2&0x1F]
 		fallthrough
 	case 3:
 		val |= uint32(src[si+2]) << 8
-		dst[di+4] = encTableLower[val>>7&0x1F]
+		dst[di+4] = table[val>>7&0x1F]
 		fallthrough
 	case 2:
 		val |= uint32(src[si+1]) << 16
-		dst[di+3] = encTableLower[val>>12&0x1F]
-		dst[di+2] = encTableLower[val>>17&0x1F]
+		dst[di+3] = table[val>>12&0x1F]
+		dst[di+2] = table[val>>17&0x1F]
 		fallthrough
 	case 1:
 		val |= uint32(src[si+0]) << 24
-		dst[di+1] = encTableLower[val>>22&0x1F]
-		dst[di+0] = encTableLower[val>>27&0x1F]
+		dst[di+1] = table[val>>22&0x1F]
+		dst[di+0] = table[val>>27&0x1F]
+	}
+}
+
+// TryEncode is like [Encode], but it returns the number of bytes written to
+// dst, or [io.ErrShortBuffer] if dst is shorter than [EncodedLen](len(src)),
+// in which case nothing is written.
+func TryEncode(dst, src []byte) (n int, err error) {
+	n = EncodedLen(len(src))
+	if len(dst) < n {
+		return 0, io.ErrShortBuffer
+	}
+	Encode(dst, src)
+	return n, nil
+}
+
+// TryEncodeLower is like [TryEncode], but uses the lowercase variation of the
+// encoding.
+func TryEncodeLower(dst, src []byte) (n int, err error) {
+	n = EncodedLen(len(src))
+	if len(dst) < n {
+		return 0, io.ErrShortBuffer
 	}
+	EncodeLower(dst, src)
+	return n, nil
 }
 
 // AppendEncode appends the cford32 encoded src to dst
@@ -421,11 +416,46 @@ func EncodeToStringLower(src []byte) string {
 	return string(buf)
 }
 
+// quantumLen is the number of bytes decoded from a final quantum of the given
+// length. Quanta of 1, 3 and 6 characters can't be produced by the encoder,
+// and are ignored.
+var quantumLen = [9]int{0, 0, 1, 0, 2, 3, 0, 4, 5}
+
+// decode decodes src into dst, reporting invalid input as a [DecodeError]
+// whose offset is the position in src.
 func decode(dst, src []byte) (n int, err error) {
+	n, _, err = decodeWith(dst, src, true)
+	return n, err
+}
+
+// decodeCorrupt is like decode, but reports invalid input as a
+// [CorruptInputError] whose offset does not count newline characters.
+func decodeCorrupt(dst, src []byte) (n int, err error) {
+	n, newlines, err := decodeWith(dst, src, true)
+	if derr, ok := err.(DecodeError); ok {
+		err = CorruptInputError(derr.Offset - int64(newlines))
+	}
+	return n, err
+}
+
+// decodeWith decodes src into dst. If swar is set, runs of whole quanta are
+// decoded using the SWAR implementation; the scalar one is only used for
+// the quanta it can't handle, which contain newlines or invalid characters,
+// or are at the end of the input. newlines is the number of newline
+// characters skipped before the invalid character, if any.
+func decodeWith(dst, src []byte, swar bool) (n, newlines int, err error) {
 	dsti := 0
 	olen := len(src)
 
 	for len(src) > 0 {
+		if swar {
+			nd, ns := decodeSWAR(dst[dsti:], src)
+			n, dsti, src = n+nd, dsti+nd, src[ns:]
+			if len(src) == 0 {
+				break
+			}
+		}
+
 		// Decode quantum using the base32 alphabet
 		var dbuf [8]byte
 		dlen := 8
@@ -438,13 +468,21 @@ func decode(dst, src []byte) (n int, err error) {
 			}
 			in := src[0]
 			src = src[1:]
+			if isNewline(in) {
+				newlines++
+				continue
+			}
 			dbuf[j] = decTable[in]
 			if dbuf[j] == 0xFF {
-				return n, CorruptInputError(olen - len(src) - 1)
+				return n, newlines, DecodeError{Offset: int64(olen - len(src) - 1), Char: in, Err: ErrInvalidChar}
 			}
 			j++
 		}
 
+		if dsti+quantumLen[dlen] > len(dst) {
+			return n, newlines, io.ErrShortBuffer
+		}
+
 		// Pack 8x 5-bit source blocks into 5 byte destination
 		// quantum
 		switch dlen {
@@ -470,16 +508,31 @@ func decode(dst, src []byte) (n int, err error) {
 		}
 		dsti += 5
 	}
-	return n, nil
+	return n, newlines, nil
+}
+
+// defaultBufSize is the size of the buffers used by [NewEncoder] and
+// [NewDecoder].
+const defaultBufSize = 1024
+
+// bufSize rounds size down to a whole number of 8-byte encoded quanta,
+// with a minimum of one quantum.
+func bufSize(size int) int {
+	if size < 8 {
+		return 8
+	}
+	return size - size%8
 }
 
 type encoder struct {
 	err  error
 	w    io.Writer
 	enc  func(dst, src []byte)
-	buf  [5]byte    // buffered data waiting to be encoded
-	nbuf int        // number of bytes in buf
-	out  [1024]byte // output buffer
+	buf  [5]byte // buffered data waiting to be encoded
+	nbuf int     // number of bytes in buf
+	out  []byte  // output buffer
+	in   []byte  // input buffer, used by ReadFrom
+	wrap *lineWriter
 }
 
 // NewEncoder returns a new cford32 stream encoder.
@@ -487,14 +540,30 @@ type encoder struct {
 // written to w. Base32 encodings operate in 5-byte blocks; when finished
 // writing, the caller must Close the returned encoder to flush any
 // partially written blocks.
+//
+// The returned encoder implements [io.ReaderFrom], so [io.Copy] can read
+// into its buffers directly.
 func NewEncoder(w io.Writer) io.WriteCloser {
-	return &encoder{w: w, enc: Encode}
+	return NewEncoderSize(w, defaultBufSize)
 }
 
 // NewEncoderLower is like [NewEncoder], but it uses the lowercase vairation of
 // the encoding.
 func NewEncoderLower(w io.Writer) io.WriteCloser {
-	return &encoder{w: w, enc: EncodeLower}
+	return NewEncoderLowerSize(w, defaultBufSize)
+}
+
+// NewEncoderSize is like [NewEncoder], but the returned encoder writes to w
+// in chunks of up to size bytes. size is rounded down to a multiple of 8.
+// Larger sizes reduce the number of calls to w when encoding large inputs.
+func NewEncoderSize(w io.Writer, size int) io.WriteCloser {
+	return &encoder{w: w, enc: Encode, out: make([]byte, bufSize(size))}
+}
+
+// NewEncoderLowerSize is like [NewEncoderSize], but it uses the lowercase
+// variation of the encoding.
+func NewEncoderLowerSize(w io.Writer, size int) io.WriteCloser {
+	return &encoder{w: w, enc: EncodeLower, out: make([]byte, bufSize(size))}
 }
 
 func (e *encoder) Write(p []byte) (n int, err error) {
@@ -543,6 +612,148 @@ func (e *encoder) Write(p []byte) (n int, err error) {
 	return
 }
 
+// EncoderOptions configures the encoder returned by [NewEncoderOptions].
+type EncoderOptions struct {
+	// Lower selects the lowercase variation of the encoding.
+	Lower bool
+	// BufferSize is the size of the output buffer; see [NewEncoderSize].
+	// If zero, a default size is used.
+	BufferSize int
+
+	// LineWidth, if positive, wraps the output into lines of at most
+	// LineWidth characters, not counting the line ending.
+	// When the encoder is closed, the last line is terminated as well.
+	LineWidth int
+	// LineEnding is written at the end of each line. It must consist only of
+	// '\r' and '\n' characters, which are ignored by the decoders; if
+	// empty, "\n" is used.
+	LineEnding string
+	// GroupSize, if positive, splits the output into groups of GroupSize
+	// characters separated by hyphens. To decode the output, the hyphens
+	// must be removed, or ignored using [DecoderOptions].Hyphens.
+	// When wrapping lines, a line break replaces the separator between two
+	// groups, so that each line contains as many whole groups as fit in
+	// LineWidth. If GroupSize is larger than LineWidth, lines are broken
+	// within groups, and each line starts a new group.
+	GroupSize int
+}
+
+// NewEncoderOptions is like [NewEncoder], but it configures the returned
+// encoder using opts. It panics if opts.LineEnding is invalid.
+func NewEncoderOptions(w io.Writer, opts EncoderOptions) io.WriteCloser {
+	size := opts.BufferSize
+	if size == 0 {
+		size = defaultBufSize
+	}
+	e := &encoder{w: w, enc: Encode, out: make([]byte, bufSize(size))}
+	if opts.Lower {
+		e.enc = EncodeLower
+	}
+	if opts.LineWidth > 0 || opts.GroupSize > 0 {
+		ending := opts.LineEnding
+		if ending == "" {
+			ending = "\n"
+		}
+		if strings.Trim(ending, "\r\n") != "" {
+			panic("cford32: line ending must only contain '\\r' and '\\n'")
+		}
+		e.wrap = &lineWriter{
+			w:      w,
+			width:  opts.LineWidth,
+			ending: ending,
+			group:  opts.GroupSize,
+		}
+		e.w = e.wrap
+	}
+	return e
+}
+
+// lineWriter splits the encoded data written to it in lines and groups,
+// as described in [EncoderOptions].
+type lineWriter struct {
+	w      io.Writer
+	width  int
+	ending string
+	group  int
+
+	col int // characters in the current line
+	grp int // characters in the current group
+	out []byte
+}
+
+func (l *lineWriter) Write(p []byte) (n int, err error) {
+	l.out = l.out[:0]
+	for _, c := range p {
+		switch {
+		case l.group > 0 && l.grp == l.group:
+			if l.width > 0 && l.col+1+l.group > l.width {
+				l.out = append(l.out, l.ending...)
+				l.col = 0
+			} else {
+				l.out = append(l.out, '-')
+				l.col++
+			}
+			l.grp = 0
+		case l.width > 0 && l.col == l.width:
+			l.out = append(l.out, l.ending...)
+			l.col, l.grp = 0, 0
+		}
+		l.out = append(l.out, c)
+		l.col++
+		l.grp++
+	}
+	if _, err = l.w.Write(l.out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// end terminates the last line, if any.
+func (l *lineWriter) end() error {
+	if l.width <= 0 || l.col == 0 {
+		return nil
+	}
+	l.col, l.grp = 0, 0
+	_, err := io.WriteString(l.w, l.ending)
+	return err
+}
+
+// ReadFrom implements [io.ReaderFrom]. It reads from r until EOF, encoding
+// the data directly from its input buffer, and returns the number of bytes
+// read. Like Write, it keeps any trailing partial block buffered until the
+// encoder is closed.
+func (e *encoder) ReadFrom(r io.Reader) (n int64, err error) {
+	if e.err != nil {
+		return 0, e.err
+	}
+	if e.in == nil {
+		e.in = make([]byte, len(e.out)/8*5)
+	}
+
+	// Start from the fringe left over by previous writes.
+	nin := copy(e.in, e.buf[:e.nbuf])
+	for err == nil {
+		var nr int
+		nr, err = r.Read(e.in[nin:])
+		n += int64(nr)
+		nin += nr
+
+		if full := nin - nin%5; full > 0 {
+			e.enc(e.out, e.in[:full])
+			if _, e.err = e.w.Write(e.out[:full/5*8]); e.err != nil {
+				return n, e.err
+			}
+			nin = copy(e.in, e.in[full:nin])
+		}
+	}
+	e.nbuf = copy(e.buf[:], e.in[:nin])
+
+	if err == io.EOF {
+		err = nil
+	}
+	return n, err
+}
+
 // Close flushes any pending output from the encoder.
 // It is an error to call Write after calling Close.
 func (e *encoder) Close() error {
@@ -553,18 +764,96 @@ func (e *encoder) Close() error {
 		e.nbuf = 0
 		_, e.err = e.w.Write(e.out[0:encodedLen])
 	}
+	if e.err == nil && e.wrap != nil {
+		e.err = e.wrap.end()
+	}
 	return e.err
 }
 
+type encodingReader struct {
+	err    error
+	r      io.Reader
+	enc    func(dst, src []byte)
+	buf    []byte // raw input, starting with any leftover partial block
+	nbuf   int
+	out    []byte // leftover encoded output
+	outbuf []byte
+}
+
+// NewEncodingReader returns a reader which reads raw data from r and returns
+// its cford32 encoding. It is the pull-based counterpart of [NewEncoder]: the
+// encoded output can be passed directly to any function accepting an
+// [io.Reader], without needing pipes or goroutines.
+//
+// Data is encoded in 5-byte blocks; any partial block at the end of r is
+// encoded when r returns [io.EOF].
+func NewEncodingReader(r io.Reader) io.Reader {
+	return newEncodingReader(r, Encode)
+}
+
+// NewEncodingReaderLower is like [NewEncodingReader], but it uses the lowercase
+// variation of the encoding.
+func NewEncodingReaderLower(r io.Reader) io.Reader {
+	return newEncodingReader(r, EncodeLower)
+}
+
+func newEncodingReader(r io.Reader, enc func(dst, src []byte)) *encodingReader {
+	return &encodingReader{
+		r:      r,
+		enc:    enc,
+		buf:    make([]byte, defaultBufSize/8*5),
+		outbuf: make([]byte, defaultBufSize),
+	}
+}
+
+func (e *encodingReader) Read(p []byte) (n int, err error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	for len(e.out) == 0 {
+		if e.err != nil {
+			return 0, e.err
+		}
+
+		var nr int
+		nr, e.err = e.r.Read(e.buf[e.nbuf:])
+		e.nbuf += nr
+
+		// Only encode whole blocks, unless this is the end of the input.
+		ne := e.nbuf
+		if e.err != io.EOF {
+			ne -= ne % 5
+		}
+		e.enc(e.outbuf, e.buf[:ne])
+		e.out = e.outbuf[:EncodedLen(ne)]
+		e.nbuf = copy(e.buf, e.buf[ne:e.nbuf])
+	}
+
+	n = copy(p, e.out)
+	e.out = e.out[n:]
+	return n, nil
+}
+
 // Decode decodes src using cford32. It writes at most
 // [DecodedLen](len(src)) bytes to dst and returns the number of bytes
 // written. If src contains invalid cford32 data, it will return the
 // number of bytes successfully written and [CorruptInputError].
-// Newline characters (\r and \n) are ignored.
+// Newline characters (\r and \n) are ignored, and not counted in the offset
+// of a CorruptInputError. Hyphens are not: they can be removed first using
+// [AppendStripSeparators].
+//
+// If dst is too small to hold the decoded data, Decode returns the number of
+// bytes written and [io.ErrShortBuffer]. Decode never allocates.
 func Decode(dst, src []byte) (n int, err error) {
-	buf := make([]byte, len(src))
-	l := stripNewlines(buf, src)
-	return decode(dst, buf[:l])
+	return decodeCorrupt(dst, src)
+}
+
+// DecodeDetailed is like [Decode], but it returns a [DecodeError] describing
+// the reason for the error, instead of a [CorruptInputError]. The offset of
+// the DecodeError is the position of the invalid character in src, counting
+// any newline characters before it.
+func DecodeDetailed(dst, src []byte) (n int, err error) {
+	return decode(dst, src)
 }
 
 // AppendDecode appends the cford32 decoded src to dst
@@ -580,18 +869,32 @@ func AppendDecode(dst, src []byte) ([]byte, error) {
 
 // DecodeString returns the bytes represented by the cford32 string s.
 func DecodeString(s string) ([]byte, error) {
+	// Each decoded quantum is shorter than the encoded one,
+	// so s can be decoded in place.
 	buf := []byte(s)
-	l := stripNewlines(buf, buf)
-	n, err := decode(buf, buf[:l])
+	n, err := decodeCorrupt(buf, buf)
 	return buf[:n], err
 }
 
-// stripNewlines removes newline characters and returns the number
-// of non-newline characters copied to dst.
-func stripNewlines(dst, src []byte) int {
+// isNewline reports whether b is a newline character, which is ignored when
+// decoding.
+func isNewline(b byte) bool {
+	return b == '\r' || b == '\n'
+}
+
+// isSeparator reports whether b is a separator: a newline character, or a
+// hyphen, which the specification allows to be used to split the encoded
+// data into groups.
+func isSeparator(b byte) bool {
+	return isNewline(b) || b == '-'
+}
+
+// stripSeparators removes newline characters, and hyphens if hyphens is set,
+// and returns the number of remaining characters copied to dst.
+func stripSeparators(dst, src []byte, hyphens bool) int {
 	offset := 0
 	for _, b := range src {
-		if b == '\r' || b == '\n' {
+		if isNewline(b) || hyphens && b == '-' {
 			continue
 		}
 		dst[offset] = b
@@ -600,18 +903,81 @@ func stripNewlines(dst, src []byte) int {
 	return offset
 }
 
+// AppendStripSeparators appends src to dst without its separators, and
+// returns the extended buffer. Separators are newline characters and
+// hyphens, which the specification allows to be used to split the encoded
+// data into groups, as done by [EncoderOptions].GroupSize. The result can be
+// decoded by [Decode], which does not ignore hyphens.
+//
+// To remove the separators in place, pass src[:0] as dst.
+func AppendStripSeparators(dst, src []byte) []byte {
+	for _, b := range src {
+		if !isSeparator(b) {
+			dst = append(dst, b)
+		}
+	}
+	return dst
+}
+
 type decoder struct {
-	err    error
-	r      io.Reader
-	buf    [1024]byte // leftover input
-	nbuf   int
-	out    []byte // leftover decoded output
-	outbuf [1024 / 8 * 5]byte
+	err      error
+	r        io.Reader
+	buf      []byte // leftover input
+	nbuf     int
+	out      []byte // leftover decoded output
+	outbuf   []byte
+	detailed bool // report DecodeErrors
 }
 
 // NewDecoder constructs a new base32 stream decoder.
+//
+// The returned decoder implements [io.WriterTo], so [io.Copy] can write
+// from its buffers directly.
 func NewDecoder(r io.Reader) io.Reader {
-	return &decoder{r: &newlineFilteringReader{r}}
+	return NewDecoderSize(r, defaultBufSize)
+}
+
+// NewDecoderSize is like [NewDecoder], but the returned decoder reads from r
+// in chunks of up to size bytes. size is rounded down to a multiple of 8.
+// Larger sizes reduce the number of calls to r when decoding large inputs.
+func NewDecoderSize(r io.Reader, size int) io.Reader {
+	size = bufSize(size)
+	return &decoder{
+		r:      &separatorFilteringReader{wrapped: r},
+		buf:    make([]byte, size),
+		outbuf: make([]byte, size/8*5),
+	}
+}
+
+// DecoderOptions configures the decoders returned by [NewDecoderOptions] and
+// [NewDecodingWriterOptions].
+type DecoderOptions struct {
+	// BufferSize is the size of the input buffer; see [NewDecoderSize].
+	// If zero, a default size is used.
+	BufferSize int
+	// Detailed makes the decoder report invalid input as a [DecodeError],
+	// describing the reason for the error, instead of a [CorruptInputError].
+	Detailed bool
+	// Hyphens makes the decoder ignore hyphens, like newline characters.
+	// Hyphens may be used to split the encoded data into groups, as done by
+	// [EncoderOptions].GroupSize.
+	Hyphens bool
+}
+
+// NewDecoderOptions is like [NewDecoder], but it configures the returned
+// decoder using opts.
+func NewDecoderOptions(r io.Reader, opts DecoderOptions) io.Reader {
+	size := opts.BufferSize
+	if size == 0 {
+		size = defaultBufSize
+	}
+	size = bufSize(size)
+	return &decoder{
+		r:        &separatorFilteringReader{wrapped: r, hyphens: opts.Hyphens},
+		buf:      make([]byte, size),
+		outbuf:   make([]byte, size/8*5),
+		detailed: opts.Detailed,
+	}
 }
 
 func readEncodedData(r io.Reader, buf []byte) (n int, err error) {
@@ -671,7 +1037,7 @@ func (d *decoder) Read(p []byte) (n int, err error) {
 	}
 
 	if err != nil && (d.err == nil || d.err == io.EOF) {
-		d.err = err
+		d.err = d.decodeError(err)
 	}
 
 	if len(d.out) > 0 {
@@ -685,19 +1051,183 @@ func (d *decoder) Read(p []byte) (n int, err error) {
 	return n, d.err
 }
 
-type newlineFilteringReader struct {
+// WriteTo implements [io.WriterTo]. It decodes the data from the underlying
+// reader until EOF, writing it to w and returning the number of bytes
+// written.
+func (d *decoder) WriteTo(w io.Writer) (n int64, err error) {
+	// Use leftover decoded output from last read.
+	if len(d.out) > 0 {
+		nw, err := w.Write(d.out)
+		n += int64(nw)
+		d.out = d.out[nw:]
+		if err != nil {
+			return n, err
+		}
+	}
+
+	for d.err == nil {
+		var nn int
+		nn, d.err = d.r.Read(d.buf[d.nbuf:])
+		d.nbuf += nn
+
+		// Only decode whole quanta, unless this is the end of the input.
+		nr := d.nbuf
+		if d.err != io.EOF {
+			nr -= nr % 8
+		}
+		nw, derr := decode(d.outbuf, d.buf[:nr])
+		d.nbuf = copy(d.buf, d.buf[nr:d.nbuf])
+		if derr != nil && (d.err == nil || d.err == io.EOF) {
+			d.err = d.decodeError(derr)
+		}
+
+		if nw > 0 {
+			nw, err = w.Write(d.outbuf[:nw])
+			n += int64(nw)
+			if err != nil {
+				return n, err
+			}
+		}
+	}
+
+	if d.err == io.EOF {
+		return n, nil
+	}
+	return n, d.err
+}
+
+// decodeError returns the error to report for the decoding error err.
+func (d *decoder) decodeError(err error) error {
+	if d.detailed {
+		return err
+	}
+	return corruptInput(err)
+}
+
+type decodingWriter struct {
+	err      error
+	w        io.Writer
+	buf      [8]byte // encoded characters waiting to be decoded
+	nbuf     int     // number of bytes in buf
+	off      int64   // offset of the next input byte
+	out      []byte  // output buffer
+	hyphens  bool    // ignore hyphens
+	detailed bool    // report DecodeErrors
+}
+
+// NewDecodingWriter returns a new cford32 stream decoder which decodes the
+// data written to it and writes the result to w. It is the push-based
+// counterpart of [NewDecoder].
+//
+// Like [Decode], newline characters (\r and \n) are ignored; they may appear
+// anywhere in the input, and encoded data may be split across writes at any
+// point. When finished writing, the caller must Close the returned decoder to
+// decode the final partial block, if any. Close returns
+// [io.ErrUnexpectedEOF] if the input ends with an incomplete block, which
+// could not have been created by an encoder.
+//
+// Invalid input is reported as a [CorruptInputError], whose offset is counted
+// from the start of the data written to the decoder.
+func NewDecodingWriter(w io.Writer) io.WriteCloser {
+	return &decodingWriter{w: w, out: make([]byte, defaultBufSize/8*5)}
+}
+
+// NewDecodingWriterOptions is like [NewDecodingWriter], but it configures the
+// returned decoder using opts. BufferSize is the size of the encoded data
+// decoded before writing to w.
+func NewDecodingWriterOptions(w io.Writer, opts DecoderOptions) io.WriteCloser {
+	size := opts.BufferSize
+	if size == 0 {
+		size = defaultBufSize
+	}
+	return &decodingWriter{
+		w:        w,
+		out:      make([]byte, bufSize(size)/8*5),
+		hyphens:  opts.Hyphens,
+		detailed: opts.Detailed,
+	}
+}
+
+func (d *decodingWriter) Write(p []byte) (n int, err error) {
+	if d.err != nil {
+		return 0, d.err
+	}
+
+	nout := 0
+	for n < len(p) {
+		c := p[n]
+		if isNewline(c) || d.hyphens && c == '-' {
+			n++
+			continue
+		}
+		if decTable[c] == 0xFF {
+			d.err = DecodeError{Offset: d.off + int64(n), Char: c, Err: ErrInvalidChar}
+			if !d.detailed {
+				d.err = corruptInput(d.err)
+			}
+			break
+		}
+		d.buf[d.nbuf] = c
+		d.nbuf++
+		n++
+
+		if d.nbuf < 8 {
+			continue
+		}
+		decode(d.out[nout:], d.buf[:])
+		d.nbuf = 0
+		nout += 5
+		if nout == len(d.out) {
+			if _, err = d.w.Write(d.out); err != nil {
+				d.err = err
+				return n, err
+			}
+			nout = 0
+		}
+	}
+	d.off += int64(n)
+
+	if nout > 0 {
+		if _, err = d.w.Write(d.out[:nout]); err != nil {
+			d.err = err
+			return n, err
+		}
+	}
+	return n, d.err
+}
+
+// Close decodes and flushes the final partial block written to the decoder.
+// It is an error to call Write after calling Close.
+func (d *decodingWriter) Close() error {
+	if d.err != nil || d.nbuf == 0 {
+		return d.err
+	}
+	switch d.nbuf {
+	case 1, 3, 6:
+		// No number of trailing bytes is encoded in these lengths.
+		d.err = io.ErrUnexpectedEOF
+	default:
+		nw, _ := decode(d.out, d.buf[:d.nbuf])
+		_, d.err = d.w.Write(d.out[:nw])
+	}
+	d.nbuf = 0
+	return d.err
+}
+
+type separatorFilteringReader struct {
 	wrapped io.Reader
+	hyphens bool
 }
 
-func (r *newlineFilteringReader) Read(p []byte) (int, error) {
+func (r *separatorFilteringReader) Read(p []byte) (int, error) {
 	n, err := r.wrapped.Read(p)
 	for n > 0 {
 		s := p[0:n]
-		offset := stripNewlines(s, s)
+		offset := stripSeparators(s, s, r.hyphens)
 		if err != nil || offset > 0 {
 			return offset, err
 		}
-		// Previous buffer entirely whitespace, read again
+		// Previous buffer entirely separators, read again
 		n, err = r.wrapped.Read(p)
 	}
 	return n, err
@@ -10,6 +10,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -447,6 +448,9 @@ func TestDecodeCorrupt(t *testing.T) {
 		{"x===", 1},
 		{"AA=A====", 2},
 		{"AAA=AAAA", 3},
+		{"AA-AAAAA", 2},
+		{"AA\nA-=AAAA", 3},
+		{"AAAAAAAA\r\nAA!A", 10},
 		// Much fewer cases compared to Go as there are much fewer cases where input
 		// can be "corrupted".
 	}
@@ -683,3 +687,358 @@ func TestDecodedLen(t *testing.T) {
 		}
 	}
 }
+
+// onlyReader and onlyWriter hide any io.ReaderFrom or io.WriterTo
+// implementations, forcing io.Copy to go through Read and Write.
+type onlyReader struct{ io.Reader }
+
+type onlyWriter struct{ io.Writer }
+
+func TestEncoderReadFrom(t *testing.T) {
+	input := []byte(bigtest.decoded)
+	for _, size := range []int{0, 8, 13, 16, 1024} {
+		for split := 0; split <= 6; split++ {
+			bb := &strings.Builder{}
+			encoder := NewEncoderSize(bb, size)
+			_, err := encoder.Write(input[:split])
+			testEqual(t, "Write gave error %v, want %v", err, error(nil))
+			n, err := io.Copy(encoder, iotest.HalfReader(bytes.NewReader(input[split:])))
+			testEqual(t, "io.Copy gave error %v, want %v", err, error(nil))
+			testEqual(t, "io.Copy gave length %v, want %v", n, int64(len(input)-split))
+			err = encoder.Close()
+			testEqual(t, "Close gave error %v, want %v", err, error(nil))
+			testEqual(t, "Encoding/%d of %q = %q, want %q", size, bigtest.decoded, bb.String(), bigtest.encoded)
+		}
+	}
+}
+
+func TestDecoderWriteTo(t *testing.T) {
+	for _, size := range []int{0, 8, 13, 16, 1024} {
+		for _, p := range append(pairs, bigtest) {
+			bb := &strings.Builder{}
+			decoder := NewDecoderSize(strings.NewReader(p.encoded), size)
+			n, err := io.Copy(bb, decoder)
+			testEqual(t, "io.Copy gave error %v, want %v", err, error(nil))
+			testEqual(t, "io.Copy gave length %v, want %v", n, int64(len(p.decoded)))
+			testEqual(t, "Decoding/%d of %q = %q, want %q", size, p.encoded, bb.String(), p.decoded)
+		}
+	}
+
+	// Mixing Read and WriteTo.
+	decoder := NewDecoder(strings.NewReader(bigtest.encoded))
+	buf := make([]byte, 3)
+	n, err := decoder.Read(buf)
+	testEqual(t, "Read gave error %v, want %v", err, error(nil))
+	bb := &strings.Builder{}
+	bb.Write(buf[:n])
+	_, err = io.Copy(bb, decoder)
+	testEqual(t, "io.Copy gave error %v, want %v", err, error(nil))
+	testEqual(t, "Decoding of %q = %q, want %q", bigtest.encoded, bb.String(), bigtest.decoded)
+
+	// Errors from the reader and from the decoding are propagated, the latter
+	// with the same offset as Read.
+	badErr := errors.New("bad reader error")
+	_, err = io.Copy(io.Discard, NewDecoder(&badReader{data: []byte("d1jprv3fexqq4v34"), errs: []error{badErr}}))
+	testEqual(t, "io.Copy gave error %v, want %v", err, badErr)
+	n64, err := io.Copy(io.Discard, NewDecoder(strings.NewReader("csqpyrk1u")))
+	testEqual(t, "io.Copy gave error %v, want %v", err, error(CorruptInputError(0)))
+	testEqual(t, "io.Copy gave length %v, want %v", n64, int64(5))
+}
+
+// repeatReader fills every read with a repeating pattern, until n bytes have
+// been read.
+type repeatReader struct {
+	pattern []byte
+	off     int
+	n       int64
+}
+
+func (r *repeatReader) Read(p []byte) (int, error) {
+	if r.n <= 0 {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > r.n {
+		p = p[:r.n]
+	}
+	for i := range p {
+		p[i] = r.pattern[r.off]
+		r.off = (r.off + 1) % len(r.pattern)
+	}
+	r.n -= int64(len(p))
+	return len(p), nil
+}
+
+const streamBenchSize = 1 << 30
+
+var (
+	streamBenchSizes = []int{defaultBufSize, 32 << 10, 256 << 10}
+
+	encPattern = bytes.Repeat([]byte("hello"), 1<<10)
+	decPattern = bytes.Repeat([]byte("D1JPRV3F"), 1<<10)
+)
+
+func BenchmarkEncoderStream(b *testing.B) {
+	for _, size := range streamBenchSizes {
+		b.Run(fmt.Sprintf("ReadFrom/%d", size), func(b *testing.B) {
+			b.SetBytes(streamBenchSize)
+			for i := 0; i < b.N; i++ {
+				enc := NewEncoderSize(io.Discard, size)
+				io.Copy(enc, &repeatReader{pattern: encPattern, n: streamBenchSize})
+				enc.Close()
+			}
+		})
+	}
+	b.Run("Write", func(b *testing.B) {
+		b.SetBytes(streamBenchSize)
+		for i := 0; i < b.N; i++ {
+			enc := NewEncoder(io.Discard)
+			io.Copy(onlyWriter{enc}, &repeatReader{pattern: encPattern, n: streamBenchSize})
+			enc.Close()
+		}
+	})
+}
+
+func BenchmarkDecoderStream(b *testing.B) {
+	const encodedSize = streamBenchSize / 5 * 8
+	for _, size := range streamBenchSizes {
+		b.Run(fmt.Sprintf("WriteTo/%d", size), func(b *testing.B) {
+			b.SetBytes(encodedSize)
+			for i := 0; i < b.N; i++ {
+				dec := NewDecoderSize(&repeatReader{pattern: decPattern, n: encodedSize}, size)
+				io.Copy(io.Discard, dec)
+			}
+		})
+	}
+	b.Run("Read", func(b *testing.B) {
+		b.SetBytes(encodedSize)
+		for i := 0; i < b.N; i++ {
+			dec := NewDecoder(&repeatReader{pattern: decPattern, n: encodedSize})
+			io.Copy(io.Discard, onlyReader{dec})
+		}
+	})
+}
+
+func TestEncodingReader(t *testing.T) {
+	for _, p := range append(pairs, bigtest) {
+		got, err := io.ReadAll(NewEncodingReader(strings.NewReader(p.decoded)))
+		testEqual(t, "ReadAll(NewEncodingReader(%q)) gave error %v, want %v", p.decoded, err, error(nil))
+		testEqual(t, "NewEncodingReader(%q) = %q, want %q", p.decoded, string(got), p.encoded)
+
+		got, err = io.ReadAll(NewEncodingReaderLower(iotest.OneByteReader(strings.NewReader(p.decoded))))
+		testEqual(t, "ReadAll(NewEncodingReaderLower(%q)) gave error %v, want %v", p.decoded, err, error(nil))
+		testEqual(t, "NewEncodingReaderLower(%q) = %q, want %q", p.decoded, string(got), strings.ToLower(p.encoded))
+
+		err = iotest.TestReader(NewEncodingReader(strings.NewReader(p.decoded)), []byte(p.encoded))
+		if err != nil {
+			t.Errorf("TestReader(NewEncodingReader(%q)): %v", p.decoded, err)
+		}
+	}
+
+	// Reading from the encoder should return the same result as the
+	// push-based encoder, on inputs larger than the internal buffers.
+	raw := make([]byte, 3*defaultBufSize+1)
+	for i := range raw {
+		raw[i] = byte(i * 7)
+	}
+	got, err := io.ReadAll(iotest.HalfReader(NewEncodingReader(iotest.DataErrReader(bytes.NewReader(raw)))))
+	testEqual(t, "ReadAll gave error %v, want %v", err, error(nil))
+	testEqual(t, "NewEncodingReader(raw) = %q, want %q", string(got), EncodeToString(raw))
+
+	// Errors from the underlying reader are returned after the data
+	// encoded so far.
+	badErr := errors.New("bad reader error")
+	got, err = io.ReadAll(NewEncodingReader(&badReader{data: []byte("helloworld!"), errs: []error{badErr}}))
+	testEqual(t, "ReadAll gave error %v, want %v", err, badErr)
+	testEqual(t, "NewEncodingReader(bad) = %q, want %q", string(got), "D1JPRV3FEXQQ4V34")
+}
+
+func TestDecodingWriter(t *testing.T) {
+	for _, p := range append(pairs, bigtest) {
+		for bs := 1; bs <= 12; bs++ {
+			bb := &strings.Builder{}
+			decoder := NewDecodingWriter(bb)
+			input := []byte(p.encoded)
+			for pos := 0; pos < len(input); pos += bs {
+				end := min(pos+bs, len(input))
+				n, err := decoder.Write(input[pos:end])
+				testEqual(t, "Write(%q) gave error %v, want %v", input[pos:end], err, error(nil))
+				testEqual(t, "Write(%q) gave length %v, want %v", input[pos:end], n, end-pos)
+			}
+			err := decoder.Close()
+			testEqual(t, "Close gave error %v, want %v", err, error(nil))
+			testEqual(t, "Decoding/%d of %q = %q, want %q", bs, p.encoded, bb.String(), p.decoded)
+		}
+	}
+
+	// Large inputs, with newlines.
+	raw := make([]byte, 3*defaultBufSize+1)
+	for i := range raw {
+		raw[i] = byte(i * 7)
+	}
+	encoded := EncodeToString(raw)
+	bb := &bytes.Buffer{}
+	decoder := NewDecodingWriter(bb)
+	for i := 0; i < len(encoded); i += 76 {
+		io.WriteString(decoder, encoded[i:min(i+76, len(encoded))]+"\r\n")
+	}
+	err := decoder.Close()
+	testEqual(t, "Close gave error %v, want %v", err, error(nil))
+	if !bytes.Equal(bb.Bytes(), raw) {
+		t.Errorf("Decoding of %d-byte string with newlines failed", len(raw))
+	}
+}
+
+func TestDecodingWriterError(t *testing.T) {
+	testCases := []struct {
+		chunks   []string
+		res      string
+		writeErr error
+		closeErr error
+	}{
+		{[]string{"CSQPYRK1", "U"}, "fooba", CorruptInputError(8), CorruptInputError(8)},
+		{[]string{"CSQ\nPYRK1\n", "E8", "\nAB=D"}, "fooba", CorruptInputError(15), CorruptInputError(15)},
+		{[]string{"CSQPYRK1", "E"}, "fooba", nil, io.ErrUnexpectedEOF},
+		{[]string{"CSQPYRK1", "E8E"}, "fooba", nil, io.ErrUnexpectedEOF},
+		{[]string{"CSQPYRK1", "E8E8E8"}, "fooba", nil, io.ErrUnexpectedEOF},
+	}
+	for _, tc := range testCases {
+		bb := &strings.Builder{}
+		decoder := NewDecodingWriter(bb)
+		var err error
+		for _, chunk := range tc.chunks {
+			if _, err = decoder.Write([]byte(chunk)); err != nil {
+				break
+			}
+		}
+		testEqual(t, "Write(%q) gave error %v, want %v", tc.chunks, err, tc.writeErr)
+		err = decoder.Close()
+		testEqual(t, "Close after %q gave error %v, want %v", tc.chunks, err, tc.closeErr)
+		testEqual(t, "Decoding of %q = %q, want %q", tc.chunks, bb.String(), tc.res)
+	}
+}
+
+func TestEncoderOptions(t *testing.T) {
+	testCases := []struct {
+		opts EncoderOptions
+		want string
+	}{
+		{EncoderOptions{}, bigtest.encoded},
+		{EncoderOptions{Lower: true, BufferSize: 8}, strings.ToLower(bigtest.encoded)},
+		{EncoderOptions{LineWidth: 20}, "AHVP2WS0C9S6JV3CD5KJ\nR831DSJ20X38CMG76V39\nEHM7J83MDXV6AWR\n"},
+		{EncoderOptions{LineWidth: 55}, bigtest.encoded + "\n"},
+		{EncoderOptions{LineWidth: 16, GroupSize: 4, LineEnding: "\r\n"}, "AHVP-2WS0-C9S6\r\nJV3C-D5KJ-R831\r\nDSJ2-0X38-CMG7\r\n6V39-EHM7-J83M\r\nDXV6-AWR\r\n"},
+		{EncoderOptions{GroupSize: 5, Lower: true}, "ahvp2-ws0c9-s6jv3-cd5kj-r831d-sj20x-38cmg-76v39-ehm7j-83mdx-v6awr"},
+		{EncoderOptions{LineWidth: 25, GroupSize: 12}, "AHVP2WS0C9S6-JV3CD5KJR831\nDSJ20X38CMG7-6V39EHM7J83M\nDXV6AWR\n"},
+		{EncoderOptions{LineWidth: 8, GroupSize: 10}, "AHVP2WS0\nC9S6JV3C\nD5KJR831\nDSJ20X38\nCMG76V39\nEHM7J83M\nDXV6AWR\n"},
+	}
+	for _, tc := range testCases {
+		for bs := 1; bs <= 12; bs++ {
+			bb := &strings.Builder{}
+			encoder := NewEncoderOptions(bb, tc.opts)
+			input := []byte(bigtest.decoded)
+			for pos := 0; pos < len(input); pos += bs {
+				encoder.Write(input[pos:min(pos+bs, len(input))])
+			}
+			err := encoder.Close()
+			testEqual(t, "Close gave error %v, want %v", err, error(nil))
+			testEqual(t, "Encoding/%d with %+v = %q, want %q", bs, tc.opts, bb.String(), tc.want)
+
+			stripped := string(AppendStripSeparators(nil, []byte(bb.String())))
+			dec, err := DecodeString(stripped)
+			testEqual(t, "DecodeString(%q) gave error %v, want %v", stripped, err, error(nil))
+			testEqual(t, "DecodeString(%q) = %q, want %q", stripped, string(dec), bigtest.decoded)
+		}
+	}
+
+	// No output at all produces no line ending.
+	bb := &strings.Builder{}
+	encoder := NewEncoderOptions(bb, EncoderOptions{LineWidth: 76})
+	encoder.Close()
+	testEqual(t, "Encoding of empty input = %q, want %q", bb.String(), "")
+
+	assert.Panics(t, func() {
+		NewEncoderOptions(io.Discard, EncoderOptions{LineWidth: 76, LineEnding: " "})
+	})
+}
+
+func TestSeparators(t *testing.T) {
+	// Each of these should decode to the string "helloworld", without errors,
+	// once the hyphens are removed or ignored.
+	examples := []string{
+		"D1JP-RV3F-EXQQ-4V34",
+		"D1JPRV3FEXQQ4V34-",
+		"-D1JPRV3F\n-EXQQ4V34\r\n",
+		"d1jp-rv3f-\nexqq-4v34",
+		"D1J--PRV-3FE-XQQ-4V3-4",
+	}
+	for _, e := range examples {
+		stripped := AppendStripSeparators(nil, []byte(e))
+		want := strings.NewReplacer("-", "", "\r", "", "\n", "").Replace(e)
+		testEqual(t, "AppendStripSeparators(%q) = %q, want %q", e, string(stripped), want)
+		testStringEncoding(t, "helloworld", []string{string(stripped)})
+
+		// Hyphens are not ignored by default.
+		_, err := DecodeString(e)
+		testEqual(t, "DecodeString(%q) gave error %v, want %v", e, err, error(CorruptInputError(strings.IndexByte(e, '-'))))
+
+		opts := DecoderOptions{Hyphens: true}
+		got, err := io.ReadAll(NewDecoderOptions(strings.NewReader(e), opts))
+		testEqual(t, "NewDecoderOptions(%q) gave error %v, want %v", e, err, error(nil))
+		testEqual(t, "NewDecoderOptions(%q) = %q, want %q", e, string(got), "helloworld")
+
+		bb := &strings.Builder{}
+		w := NewDecodingWriterOptions(bb, opts)
+		io.WriteString(w, e)
+		err = w.Close()
+		testEqual(t, "NewDecodingWriterOptions(%q) gave error %v, want %v", e, err, error(nil))
+		testEqual(t, "NewDecodingWriterOptions(%q) = %q, want %q", e, bb.String(), "helloworld")
+	}
+
+	// Stripping in place.
+	b := []byte("D1JP-RV3F\nEXQQ-4V34")
+	testEqual(t, "AppendStripSeparators in place = %q, want %q", string(AppendStripSeparators(b[:0], b)), "D1JPRV3FEXQQ4V34")
+}
+
+func TestDecodeAllocs(t *testing.T) {
+	src := []byte("D1JPRV3F\nEXQQ\r\n4V34")
+	dst := make([]byte, DecodedLen(len(src)))
+	allocs := testing.AllocsPerRun(100, func() {
+		Decode(dst, src)
+	})
+	testEqual(t, "Decode allocations = %v, want %v", allocs, float64(0))
+}
+
+func TestDecodeShortBuffer(t *testing.T) {
+	for _, p := range append(pairs, bigtest) {
+		for l := 0; l < len(p.decoded); l++ {
+			dst := make([]byte, l)
+			n, err := Decode(dst, []byte(p.encoded))
+			testEqual(t, "Decode(%q) into %d bytes gave error %v, want %v", p.encoded, l, err, io.ErrShortBuffer)
+			if n > l || string(dst[:n]) != p.decoded[:n] {
+				t.Errorf("Decode(%q) into %d bytes = %q, want prefix of %q", p.encoded, l, dst[:n], p.decoded)
+			}
+		}
+	}
+}
+
+func TestTryEncode(t *testing.T) {
+	for _, p := range append(pairs, bigtest) {
+		dst := make([]byte, len(p.encoded))
+		n, err := TryEncode(dst, []byte(p.decoded))
+		testEqual(t, "TryEncode(%q) gave error %v, want %v", p.decoded, err, error(nil))
+		testEqual(t, "TryEncode(%q) = %q, want %q", p.decoded, string(dst[:n]), p.encoded)
+		n, err = TryEncodeLower(dst, []byte(p.decoded))
+		testEqual(t, "TryEncodeLower(%q) gave error %v, want %v", p.decoded, err, error(nil))
+		testEqual(t, "TryEncodeLower(%q) = %q, want %q", p.decoded, string(dst[:n]), strings.ToLower(p.encoded))
+
+		if len(p.encoded) == 0 {
+			continue
+		}
+		n, err = TryEncode(dst[:len(dst)-1], []byte(p.decoded))
+		testEqual(t, "TryEncode(%q) into short buffer gave error %v, want %v", p.decoded, err, io.ErrShortBuffer)
+		testEqual(t, "TryEncode(%q) into short buffer gave length %v, want %v", p.decoded, n, 0)
+		n, err = TryEncodeLower(dst[:len(dst)-1], []byte(p.decoded))
+		testEqual(t, "TryEncodeLower(%q) into short buffer gave error %v, want %v", p.decoded, err, io.ErrShortBuffer)
+		testEqual(t, "TryEncodeLower(%q) into short buffer gave length %v, want %v", p.decoded, n, 0)
+	}
+}
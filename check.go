@@ -0,0 +1,269 @@
+package cford32
+
+import (
+	"sort"
+	"strconv"
+)
+
+// checkTable contains the symbols used for the check symbols, as given in the
+// specification. The check symbols add 5 symbols to the encoding table, for
+// a total of 37.
+const checkTable = encTable + "*~$=U"
+
+// checkValue returns the value of the check symbol c, or 0xFF if c is not a
+// valid check symbol.
+func checkValue(c byte) byte {
+	switch c {
+	case '*':
+		return 32
+	case '~':
+		return 33
+	case '$':
+		return 34
+	case '=':
+		return 35
+	case 'U', 'u':
+		return 36
+	}
+	return decTable[c]
+}
+
+// CheckSymbol returns the check symbol of the encoded data in src, as given in
+// the specification: the symbol for the value of src, read as a base-32
+// number, modulo 37. Separators are ignored: newline characters and, unlike
+// [Decode], hyphens, so that data split into groups has the same check
+// symbol as the data.
+//
+// The check symbol detects any single wrong symbol and any transposition of
+// two adjacent symbols. It is usually appended to src, as in [AppendCheck].
+// If src contains an invalid character, a [DecodeError] is returned.
+func CheckSymbol(src []byte) (byte, error) {
+	sum := 0
+	for i, c := range src {
+		if isSeparator(c) {
+			continue
+		}
+		v := decTable[c]
+		if v == 0xFF {
+			return 0, DecodeError{Offset: int64(i), Char: c, Err: ErrInvalidChar}
+		}
+		sum = (sum*32 + int(v)) % 37
+	}
+	return checkTable[sum], nil
+}
+
+// AppendCheck appends src and its check symbol to dst, returning the extended
+// buffer. If src contains an invalid character, dst is returned unchanged
+// together with a [DecodeError].
+func AppendCheck(dst, src []byte) ([]byte, error) {
+	c, err := CheckSymbol(src)
+	if err != nil {
+		return dst, err
+	}
+	dst = append(dst, src...)
+	return append(dst, c), nil
+}
+
+// VerifyCheck verifies that the last character of b is the check symbol of
+// the rest of b, as appended by [AppendCheck]. Check symbols are
+// case-insensitive.
+//
+// If it isn't, a [DecodeError] with reason [ErrCheckSymbol] is returned,
+// pointing to the check symbol; [SuggestCorrections] may then be used to find
+// the likely intended input.
+//
+// Like CheckSymbol, VerifyCheck ignores hyphens, so input which passes the
+// check may still need [AppendStripSeparators] before it can be decoded.
+func VerifyCheck(b []byte) error {
+	last := len(b) - 1
+	for last >= 0 && isSeparator(b[last]) {
+		last--
+	}
+	if last < 0 {
+		return DecodeError{Offset: 0, Err: ErrInvalidLength}
+	}
+	want, err := CheckSymbol(b[:last])
+	if err != nil {
+		return err
+	}
+	if checkValue(b[last]) != checkValue(want) {
+		return DecodeError{Offset: int64(last), Char: b[last], Err: ErrCheckSymbol}
+	}
+	return nil
+}
+
+// EditKind is the kind of edit proposed by a [Suggestion].
+type EditKind uint8
+
+// The edits which may be proposed by [SuggestCorrections].
+const (
+	EditSubstitution  EditKind = iota // a symbol was misread as another
+	EditTransposition                 // two adjacent symbols were swapped
+	EditInsertion                     // a symbol was left out of the input
+	EditDeletion                      // an extra symbol was added to the input
+)
+
+func (k EditKind) String() string {
+	switch k {
+	case EditSubstitution:
+		return "substitution"
+	case EditTransposition:
+		return "transposition"
+	case EditInsertion:
+		return "insertion"
+	case EditDeletion:
+		return "deletion"
+	}
+	return "EditKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// Suggestion is a possible correction of an input which failed to verify
+// with its check symbol.
+type Suggestion struct {
+	// Text is the corrected input, including its check symbol, which passes
+	// [VerifyCheck]. It is in canonical form: uppercase, with aliases replaced
+	// and without separators.
+	Text string
+	// Kind is the edit applied to the input to obtain Text.
+	Kind EditKind
+	// Offset is the position of the edit in the input, after removing
+	// separators: the substituted or deleted symbol, the first of the
+	// transposed symbols, or the symbol before which a symbol was inserted.
+	Offset int
+}
+
+// confusables contains pairs of symbols which are easily mistaken for each
+// other when reading or transcribing. Substitutions between them are ranked
+// first.
+var confusables = [...][2]byte{
+	{'5', 'S'}, {'8', 'B'}, {'2', 'Z'}, {'U', 'V'}, {'6', 'G'}, {'0', 'D'},
+}
+
+func isConfusable(a, b byte) bool {
+	for _, p := range confusables {
+		if (a == p[0] && b == p[1]) || (a == p[1] && b == p[0]) {
+			return true
+		}
+	}
+	return false
+}
+
+// SuggestCorrections proposes corrections for an input b, ending with a check
+// symbol, which fails [VerifyCheck]. It tries every substitution of a single
+// symbol, every transposition of two adjacent symbols, and every insertion or
+// deletion of a single symbol, and returns the candidates which pass the
+// check, ranked from the most to the least likely:
+//
+//  1. substitutions of confusable symbols, such as 5 and S, 8 and B,
+//     2 and Z, U and V;
+//  2. transpositions;
+//  3. other substitutions;
+//  4. insertions and deletions.
+//
+// The check symbol only detects single substitutions and transpositions, so
+// for most inputs more than one candidate is returned; all of them should be
+// considered, for instance by looking each one up. If b already passes the
+// check, SuggestCorrections returns nil. Like VerifyCheck, it ignores
+// separators, including hyphens.
+//
+// The search takes time proportional to the square of the length of b, and is
+// meant for short inputs, such as identifiers.
+func SuggestCorrections(b []byte) []Suggestion {
+	syms := make([]byte, 0, len(b))
+	for _, c := range b {
+		if isSeparator(c) {
+			continue
+		}
+		if v := checkValue(c); v != 0xFF {
+			c = checkTable[v]
+		}
+		syms = append(syms, c)
+	}
+	if len(syms) == 0 || checkPasses(syms) {
+		return nil
+	}
+
+	type ranked struct {
+		Suggestion
+		rank int
+	}
+	var res []ranked
+	cand := make([]byte, 0, len(syms)+1)
+	try := func(kind EditKind, off, rank int) {
+		if checkPasses(cand) {
+			res = append(res, ranked{Suggestion{string(cand), kind, off}, rank})
+		}
+	}
+
+	last := len(syms) - 1
+	for i, c := range syms {
+		// Only the check symbol may be one of the additional check symbols.
+		n := 32
+		if i == last {
+			n = len(checkTable)
+		}
+		for _, r := range []byte(checkTable[:n]) {
+			if r == c {
+				continue
+			}
+			cand = append(append(append(cand[:0], syms[:i]...), r), syms[i+1:]...)
+			if isConfusable(c, r) {
+				try(EditSubstitution, i, 0)
+			} else {
+				try(EditSubstitution, i, 2)
+			}
+		}
+	}
+	for i := 0; i < last; i++ {
+		if syms[i] == syms[i+1] {
+			continue
+		}
+		cand = append(cand[:0], syms...)
+		cand[i], cand[i+1] = cand[i+1], cand[i]
+		try(EditTransposition, i, 1)
+	}
+	for i := 0; i <= len(syms); i++ {
+		n := 32
+		if i == len(syms) {
+			n = len(checkTable)
+		}
+		for _, r := range []byte(checkTable[:n]) {
+			cand = append(append(append(cand[:0], syms[:i]...), r), syms[i:]...)
+			try(EditInsertion, i, 3)
+		}
+	}
+	for i := range syms {
+		cand = append(append(cand[:0], syms[:i]...), syms[i+1:]...)
+		try(EditDeletion, i, 3)
+	}
+
+	sort.SliceStable(res, func(i, j int) bool { return res[i].rank < res[j].rank })
+	seen := make(map[string]bool, len(res))
+	sugg := make([]Suggestion, 0, len(res))
+	for _, r := range res {
+		// Different edits may result in the same text, for instance
+		// inserting a symbol before or after the same symbol.
+		if !seen[r.Text] {
+			seen[r.Text] = true
+			sugg = append(sugg, r.Suggestion)
+		}
+	}
+	return sugg
+}
+
+// checkPasses reports whether syms, in canonical form and without separators,
+// consists of at least one symbol followed by its check symbol.
+func checkPasses(syms []byte) bool {
+	if len(syms) < 2 {
+		return false
+	}
+	sum := 0
+	for _, c := range syms[:len(syms)-1] {
+		v := decTable[c]
+		if v == 0xFF {
+			return false
+		}
+		sum = (sum*32 + int(v)) % 37
+	}
+	return checkValue(syms[len(syms)-1]) == byte(sum)
+}
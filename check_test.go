@@ -0,0 +1,127 @@
+package cford32
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckSymbol(t *testing.T) {
+	testCases := []struct {
+		input string
+		check byte
+	}{
+		{"", '0'},
+		{"0", '0'},
+		{"Z", 'Z'},
+		{"10", '*'},
+		{"11", '~'},
+		{"12", '$'},
+		{"13", '='},
+		{"14", 'U'},
+		{"1234", 'S'},
+		{"12-34", 'S'},
+		{"il2o", '1'},
+	}
+	for _, tc := range testCases {
+		c, err := CheckSymbol([]byte(tc.input))
+		testEqual(t, "CheckSymbol(%q) err = %v, want %v", tc.input, err, error(nil))
+		testEqual(t, "CheckSymbol(%q) = %q, want %q", tc.input, c, tc.check)
+
+		b, err := AppendCheck([]byte("x"), []byte(tc.input))
+		testEqual(t, "AppendCheck(%q) err = %v, want %v", tc.input, err, error(nil))
+		testEqual(t, "AppendCheck(%q) = %q, want %q", tc.input, string(b), "x"+tc.input+string(tc.check))
+		if tc.input != "" {
+			testEqual(t, "VerifyCheck(%q) = %v, want %v", string(b[1:]), VerifyCheck(b[1:]), error(nil))
+		}
+	}
+
+	_, err := CheckSymbol([]byte("12U4"))
+	testEqual(t, "CheckSymbol(%q) err = %v, want %v", "12U4", err, error(DecodeError{Offset: 2, Char: 'U', Err: ErrInvalidChar}))
+}
+
+func TestVerifyCheck(t *testing.T) {
+	testCases := []struct {
+		input string
+		err   error
+	}{
+		{"1234S", nil},
+		{"1234s\n", nil},
+		{"12-34-S", nil},
+		{"14u", nil},
+		{"1234T", DecodeError{Offset: 4, Char: 'T', Err: ErrCheckSymbol}},
+		{"1324S", DecodeError{Offset: 4, Char: 'S', Err: ErrCheckSymbol}},
+		{"1234!", DecodeError{Offset: 4, Char: '!', Err: ErrCheckSymbol}},
+		{"12!4S", DecodeError{Offset: 2, Char: '!', Err: ErrInvalidChar}},
+		{"", DecodeError{Offset: 0, Err: ErrInvalidLength}},
+	}
+	for _, tc := range testCases {
+		err := VerifyCheck([]byte(tc.input))
+		testEqual(t, "VerifyCheck(%q) = %v, want %v", tc.input, err, tc.err)
+	}
+}
+
+func TestSuggestCorrections(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  Suggestion
+		first bool // want must be the first suggestion
+	}{
+		{"1Z34S", Suggestion{"1234S", EditSubstitution, 1}, true},
+		{"1324S", Suggestion{"1234S", EditTransposition, 1}, true},
+		{"12-3U-S", Suggestion{"1234S", EditSubstitution, 3}, false},
+		{"1234T", Suggestion{"1234S", EditSubstitution, 4}, false},
+		{"124S", Suggestion{"1234S", EditInsertion, 2}, false},
+		{"12354S", Suggestion{"1234S", EditDeletion, 3}, false},
+		{"il34s", Suggestion{"11340", EditSubstitution, 4}, false},
+	}
+	for _, tc := range testCases {
+		sugg := SuggestCorrections([]byte(tc.input))
+		found := -1
+		for i, s := range sugg {
+			if err := VerifyCheck([]byte(s.Text)); err != nil {
+				t.Errorf("SuggestCorrections(%q) suggested %q, which fails: %v", tc.input, s.Text, err)
+			}
+			if s == tc.want {
+				found = i
+			}
+		}
+		switch {
+		case found < 0:
+			t.Errorf("SuggestCorrections(%q) = %v, want it to contain %v", tc.input, sugg, tc.want)
+		case tc.first && found != 0:
+			t.Errorf("SuggestCorrections(%q) = %v, want %v first", tc.input, sugg, tc.want)
+		}
+	}
+
+	if sugg := SuggestCorrections([]byte("1234S")); sugg != nil {
+		t.Errorf("SuggestCorrections of a valid input = %v, want nil", sugg)
+	}
+	if sugg := SuggestCorrections(nil); sugg != nil {
+		t.Errorf("SuggestCorrections(nil) = %v, want nil", sugg)
+	}
+}
+
+func TestVerifyCheckDetects(t *testing.T) {
+	// Any single substitution and adjacent transposition is detected.
+	input := []byte("D1JPRV3FEXQQ4V34")
+	b, _ := AppendCheck(nil, input)
+	for i := range input {
+		for _, r := range []byte(encTable) {
+			if r == b[i] {
+				continue
+			}
+			c := append([]byte(nil), b...)
+			c[i] = r
+			if err := VerifyCheck(c); !errors.Is(err, ErrCheckSymbol) {
+				t.Errorf("VerifyCheck(%q) = %v, want %v", c, err, ErrCheckSymbol)
+			}
+		}
+		if i+1 < len(input) && b[i] != b[i+1] {
+			c := append([]byte(nil), b...)
+			c[i], c[i+1] = c[i+1], c[i]
+			if err := VerifyCheck(c); !errors.Is(err, ErrCheckSymbol) {
+				t.Errorf("VerifyCheck(%q) = %v, want %v", c, err, ErrCheckSymbol)
+			}
+		}
+	}
+}
@@ -1,97 +1,532 @@
+// Command cford32 encodes and decodes data and uint64 IDs using Crockford's
+// base32 encoding.
+//
+// Run "cford32 help" for the list of commands, and "cford32 help COMMAND" for
+// the options of each.
 package main
 
 import (
 	"bytes"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"os"
-	"strconv"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/thehowl/cford32"
 )
 
-const usageString = `Usage: %s [OPTION...] [FILE]
-cford32 encode or decode FILE, or standard input, to standard output.
-With no FILE, or when FILE is -, read standard input.
+// bufSize is the size of the buffers used when streaming data through the
+// encoder and decoder.
+const bufSize = 64 << 10
+
+// Exit codes.
+const (
+	exitOK      = 0
+	exitFailure = 1 // invalid input, or an I/O error
+	exitUsage   = 2
+)
+
+const usageString = `Usage: cford32 COMMAND [OPTION...] [ARG...]
+
+cford32 encodes and decodes data and uint64 IDs using Crockford's base32.
 
+Commands:
+%s
+Run 'cford32 help COMMAND' for the options of a command.
+
+The exit status is 0 on success, 1 if the input is invalid or an I/O error
+occurs, and 2 if the command is used incorrectly.
 `
 
+// command is a subcommand of cford32.
+type command struct {
+	name  string
+	short string // one-line description, for the list of commands
+	run   func(e *env, args []string) error
+}
+
+var commands []command
+
+func init() {
+	// Initialized here, as the help command refers to commands.
+	commands = []command{
+		{"encode", "encode a file or standard input", cmdEncode},
+		{"decode", "decode a file or standard input", cmdDecode},
+		{"id encode", "encode uint64 numbers as IDs", cmdIDEncode},
+		{"id decode", "decode IDs to uint64 numbers", cmdIDDecode},
+		{"gen", "generate random IDs or data", cmdGen},
+		{"validate", "check that values are validly encoded", cmdValidate},
+		{"inspect", "describe encoded values", cmdInspect},
+		{"convert", "convert between cford32, hex, base64, base32 and UUIDs", cmdConvert},
+		{"scan", "find IDs in text and decode them", cmdScan},
+		{"json encode", "encode numeric IDs in JSON fields", cmdJSONEncode},
+		{"json decode", "decode IDs in JSON fields to numbers", cmdJSONDecode},
+		{"serve", "serve the commands as a JSON API over HTTP", cmdServe},
+		{"help", "show help for a command", cmdHelp},
+	}
+}
+
+// lookup returns the command named by the first words of args, and the
+// remaining arguments.
+func lookup(args []string) (*command, []string) {
+	for i := range commands {
+		c := &commands[i]
+		words := strings.Fields(c.name)
+		if len(args) >= len(words) && strings.Join(args[:len(words)], " ") == c.name {
+			return c, args[len(words):]
+		}
+	}
+	return nil, args
+}
+
+// subcommands returns the names of the subcommands of the command group
+// named name, such as "id".
+func subcommands(name string) []string {
+	var subs []string
+	for _, c := range commands {
+		if sub, ok := strings.CutPrefix(c.name, name+" "); ok {
+			subs = append(subs, sub)
+		}
+	}
+	return subs
+}
+
+// env is the environment in which a command runs.
+type env struct {
+	stdin          io.Reader
+	stdout, stderr io.Writer
+	name           string // name of the running command
+}
+
+// errUsage is returned by commands used incorrectly, after the error has
+// been reported.
+var errUsage = errors.New("usage error")
+
+// usageError is returned by commands given invalid options.
+type usageError string
+
+func (u usageError) Error() string { return string(u) }
+
+// errReported is returned by commands which failed, after the reason has been
+// reported.
+var errReported = errors.New("failed")
+
 func main() {
-	var (
-		dec = flag.Bool("d", false, "decode data")
-		lo  = flag.Bool("l", true, "use lowercase encoding")
-		u64 = flag.Bool("n", false, "encode a uint64, or decode a cford32-encoded compact uint64")
-	)
-	_ = u64
-	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, usageString, os.Args[0])
-		flag.PrintDefaults()
-	}
-	flag.Parse()
-
-	f := os.Stdin
-	var err error
-	if arg := flag.Arg(0); arg != "" && arg != "-" {
-		f, err = os.Open(arg)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "error opening %q: %v\n", arg, err)
-			os.Exit(1)
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs the command given by args, and returns the exit code.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	e := &env{stdin: stdin, stdout: stdout, stderr: stderr}
+	if len(args) == 0 {
+		e.usage(stderr)
+		return exitUsage
+	}
+	switch args[0] {
+	case "-h", "-help", "--help":
+		e.usage(stdout)
+		return exitOK
+	}
+	c, rest := lookup(args)
+	if subs := subcommands(args[0]); c == nil && len(subs) > 0 {
+		fmt.Fprintf(stderr, "cford32 %s: expected a subcommand: %s\n", args[0], strings.Join(subs, " or "))
+		fmt.Fprintf(stderr, "Run 'cford32 help' for usage.\n")
+		return exitUsage
+	}
+	if c == nil {
+		fmt.Fprintf(stderr, "cford32: unknown command %q\n", args[0])
+		fmt.Fprintf(stderr, "Run 'cford32 help' for usage.\n")
+		return exitUsage
+	}
+	e.name = c.name
+	switch err := c.run(e, rest); {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.Is(err, errUsage):
+		return exitUsage
+	case errors.As(err, new(usageError)):
+		e.errorf("%v", err)
+		return exitUsage
+	case errors.Is(err, errReported):
+		return exitFailure
+	default:
+		e.errorf("%v", err)
+		return exitFailure
+	}
+}
+
+func (e *env) usage(w io.Writer) {
+	var sb strings.Builder
+	for _, c := range commands {
+		fmt.Fprintf(&sb, "  %-11s %s\n", c.name, c.short)
+	}
+	fmt.Fprintf(w, usageString, sb.String())
+}
+
+// errorf reports an error on standard error, prefixed by the command name.
+func (e *env) errorf(format string, args ...any) {
+	fmt.Fprintf(e.stderr, "cford32 %s: %s\n", e.name, fmt.Sprintf(format, args...))
+}
+
+// flagSet returns a new flag set for the running command, using synopsis and
+// help as its usage text.
+func (e *env) flagSet(synopsis, help string) *flag.FlagSet {
+	fs := flag.NewFlagSet(e.name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: cford32 %s %s\n\n%s\n", e.name, synopsis, help)
+		n := 0
+		fs.VisitAll(func(*flag.Flag) { n++ })
+		if n > 0 {
+			fmt.Fprintf(fs.Output(), "\nOptions:\n")
+			fs.PrintDefaults()
 		}
 	}
+	return fs
+}
 
-	switch {
-	case *u64 && *dec:
-		buf, err := io.ReadAll(f)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "error reading stdin: %v\n", err)
-			os.Exit(1)
+// parse parses args using fs, and checks that the number of remaining
+// arguments is between minArgs and maxArgs; a negative maxArgs means no
+// limit.
+func (e *env) parse(fs *flag.FlagSet, args []string, minArgs, maxArgs int) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
 		}
-		n, err := cford32.Uint64(bytes.TrimSpace(buf))
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "error reading uint64: %v\n", err)
-			os.Exit(1)
+		return errUsage
+	}
+	if n := fs.NArg(); n < minArgs || (maxArgs >= 0 && n > maxArgs) {
+		fmt.Fprintf(e.stderr, "cford32 %s: wrong number of arguments\n", e.name)
+		fs.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+// open opens the named file, or returns standard input if name is "" or "-".
+func (e *env) open(name string) (io.ReadCloser, error) {
+	if name == "" || name == "-" {
+		return io.NopCloser(e.stdin), nil
+	}
+	return os.Open(name)
+}
+
+// encodeFlags are the flags shared by the commands producing encoded output.
+type encodeFlags struct {
+	lower bool
+	group int
+	check bool
+}
+
+func (o *encodeFlags) register(fs *flag.FlagSet) {
+	fs.BoolVar(&o.lower, "lower", false, "use the lowercase variation of the encoding")
+	fs.IntVar(&o.group, "group", 0, "separate groups of `N` characters with hyphens")
+	fs.BoolVar(&o.check, "check", false, "append a check symbol")
+}
+
+// decodeFlags are the flags shared by the commands reading encoded input.
+type decodeFlags struct {
+	check   bool
+	strict  bool
+	lenient bool
+}
+
+func (o *decodeFlags) register(fs *flag.FlagSet) {
+	fs.BoolVar(&o.check, "check", false, "verify and remove the check symbol at the end of the input")
+	fs.BoolVar(&o.strict, "strict", false, "reject input which is not in the canonical form produced by the encoder")
+	fs.BoolVar(&o.lenient, "lenient", false, "fold Unicode look-alike characters and remove spaces before decoding")
+}
+
+func (o *decodeFlags) validate() error {
+	if o.strict && o.lenient {
+		return usageError("-strict and -lenient are mutually exclusive")
+	}
+	return nil
+}
+
+// prepare applies the lenient and check flags to the encoded input b,
+// returning the encoded data without its check symbol and separators. id
+// reports whether b is expected to be an ID.
+//
+// The offsets of the errors found in the returned data can be moved back to
+// point into b using inputError.
+func (o *decodeFlags) prepare(b []byte, id bool) ([]byte, error) {
+	in := b
+	if o.lenient {
+		var err error
+		if b, err = cford32.AppendNormalize(nil, string(b)); err != nil {
+			return nil, err
 		}
-		fmt.Println(n)
-	case *u64 && !*dec:
-		buf, err := io.ReadAll(f)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "error reading stdin: %v\n", err)
-			os.Exit(1)
+	}
+	if o.check {
+		b = bytes.TrimRightFunc(b, func(r rune) bool { return unicode.IsSpace(r) || r == '-' })
+		if err := cford32.VerifyCheck(b); err != nil {
+			return nil, checkError(b, o.moveOffset(in, err, true), id)
 		}
-		u, err := strconv.ParseUint(string(bytes.TrimSpace(buf)), 0, 64)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "error parsing integer: %v\n", err)
-			os.Exit(1)
+		b = b[:len(b)-1]
+	}
+	return cford32.AppendStripSeparators(nil, b), nil
+}
+
+// inputError returns err with its offset, if it is a [cford32.DecodeError],
+// moved from the data returned by prepare to the input in it was prepared
+// from, so that it points at the character as given by the user.
+func (o *decodeFlags) inputError(in []byte, err error) error {
+	return o.moveOffset(in, err, false)
+}
+
+// moveOffset moves the offset of err from data prepared from in to in.
+// separators reports whether the separators were still in the data.
+func (o *decodeFlags) moveOffset(in []byte, err error, separators bool) error {
+	derr, ok := err.(cford32.DecodeError)
+	if !ok {
+		return err
+	}
+	off, end := derr.Offset, 0
+	for i := 0; i < len(in); {
+		c, size := in[i], 1
+		if o.lenient {
+			// Normalization folds each rune to at most one character.
+			_, size = utf8.DecodeRune(in[i:])
+			norm, _ := cford32.AppendNormalize(nil, string(in[i:i+size]))
+			if len(norm) == 0 {
+				i += size
+				continue
+			}
+			c = norm[0]
 		}
-		res := cford32.PutCompact(u)
-		if !*lo {
-			res = bytes.ToUpper(res)
+		if separators || !strings.ContainsRune("\r\n-", rune(c)) {
+			if off == 0 {
+				derr.Offset = int64(i)
+				return derr
+			}
+			off--
+			end = i + size
 		}
-		fmt.Println(string(res))
-	case !*u64 && *dec:
-		dec := cford32.NewDecoder(f)
-		_, err := io.Copy(os.Stdout, dec)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "error decoding: %v\n", err)
-			os.Exit(1)
+		i += size
+	}
+	// The error is at the end of the data.
+	derr.Offset = int64(end)
+	return derr
+}
+
+// checkError adds the most likely corrections of b to err, if err is a check
+// symbol mismatch. If id is set, only corrections which are valid IDs are
+// considered.
+func checkError(b []byte, err error, id bool) error {
+	if !errors.Is(err, cford32.ErrCheckSymbol) || len(b) > 64 {
+		return err
+	}
+	texts := make([]string, 0, 3)
+	for _, s := range cford32.SuggestCorrections(b) {
+		if id && cford32.ValidateUint64([]byte(s.Text[:len(s.Text)-1])) != nil {
+			continue
 		}
-	case !*u64 && !*dec:
-		var enc io.WriteCloser
-		if *lo {
-			enc = cford32.NewEncoderLower(os.Stdout)
-		} else {
-			enc = cford32.NewEncoder(os.Stdout)
+		if texts = append(texts, s.Text); len(texts) == cap(texts) {
+			break
+		}
+	}
+	if len(texts) == 0 {
+		return err
+	}
+	return fmt.Errorf("%w (did you mean %s?)", err, strings.Join(texts, ", "))
+}
+
+// addCheck appends the check symbol of the encoded data in b to b, in the
+// same case.
+func addCheck(b []byte, lower bool) []byte {
+	c, err := cford32.CheckSymbol(b)
+	if err != nil {
+		// b was produced by the encoder.
+		panic(err)
+	}
+	if lower && c >= 'A' && c <= 'Z' {
+		c += 'a' - 'A'
+	}
+	return append(b, c)
+}
+
+func cmdEncode(e *env, args []string) error {
+	fs := e.flagSet("[OPTION...] [FILE]", `Encode FILE, or standard input, to standard output.
+With no FILE, or when FILE is -, read standard input.
+
+With -check, the check symbol is appended to the last line; the whole input is
+then buffered in memory.`)
+	var o encodeFlags
+	o.register(fs)
+	wrap := fs.Int("w", 0, "wrap lines after `COLS` characters (0 disables wrapping)")
+	if err := e.parse(fs, args, 0, 1); err != nil {
+		return err
+	}
+	in, err := e.open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	var out io.Writer = e.stdout
+	var buf bytes.Buffer
+	if o.check {
+		out = &buf
+	}
+	enc := cford32.NewEncoderOptions(out, cford32.EncoderOptions{
+		Lower:      o.lower,
+		BufferSize: bufSize,
+		LineWidth:  *wrap,
+		GroupSize:  o.group,
+	})
+	if _, err := io.Copy(enc, in); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	if o.check {
+		b := addCheck(bytes.TrimRight(buf.Bytes(), "\n"), o.lower)
+		_, err = e.stdout.Write(append(b, '\n'))
+		return err
+	}
+	// The encoder terminates the last line when wrapping.
+	if *wrap <= 0 {
+		_, err = io.WriteString(e.stdout, "\n")
+	}
+	return err
+}
+
+func cmdDecode(e *env, args []string) error {
+	fs := e.flagSet("[OPTION...] [FILE]", `Decode FILE, or standard input, to standard output.
+With no FILE, or when FILE is -, read standard input.
+
+Line breaks and hyphens in the input are ignored. With -check, -strict or
+-lenient, the whole input is buffered in memory.`)
+	var o decodeFlags
+	o.register(fs)
+	if err := e.parse(fs, args, 0, 1); err != nil {
+		return err
+	}
+	if err := o.validate(); err != nil {
+		return err
+	}
+	in, err := e.open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if !o.check && !o.strict && !o.lenient {
+		_, err := io.Copy(e.stdout, newDecoder(in))
+		return err
+	}
+	raw, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	b, err := o.prepare(raw, false)
+	if err != nil {
+		return err
+	}
+	if o.strict {
+		if err := cford32.ValidateStrict(b); err != nil {
+			return o.inputError(raw, err)
 		}
-		_, err := io.Copy(enc, f)
+	}
+	b, err = appendDecode(nil, b)
+	if err != nil {
+		return o.inputError(raw, err)
+	}
+	_, err = e.stdout.Write(b)
+	return err
+}
+
+// newDecoder returns a decoder reading the encoded data from r, which ignores
+// hyphens and reports the reason for invalid input.
+func newDecoder(r io.Reader) io.Reader {
+	return cford32.NewDecoderOptions(r, cford32.DecoderOptions{
+		BufferSize: bufSize,
+		Detailed:   true,
+		Hyphens:    true,
+	})
+}
+
+// appendDecode is like cford32.AppendDecode, but it returns a
+// cford32.DecodeError for invalid input.
+func appendDecode(dst, src []byte) ([]byte, error) {
+	n := cford32.DecodedLen(len(src))
+	dst = slices.Grow(dst, n)
+	n, err := cford32.DecodeDetailed(dst[len(dst):][:n], src)
+	return dst[:len(dst)+n], err
+}
+
+func cmdValidate(e *env, args []string) error {
+	fs := e.flagSet("[OPTION...] [VALUE...]", `Check that each VALUE is validly encoded, or with no VALUE, that standard
+input is. Invalid values are reported on standard error.`)
+	var o decodeFlags
+	o.register(fs)
+	id := fs.Bool("id", false, "validate uint64 IDs instead of data")
+	if err := e.parse(fs, args, 0, -1); err != nil {
+		return err
+	}
+	if err := o.validate(); err != nil {
+		return err
+	}
+
+	if fs.NArg() == 0 {
+		b, err := io.ReadAll(e.stdin)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "error encoding: %v\n", err)
-			os.Exit(1)
+			return err
+		}
+		if *id {
+			b = bytes.TrimSpace(b)
 		}
-		if err := enc.Close(); err != nil {
-			fmt.Fprintf(os.Stderr, "error encoding: %v\n", err)
-			os.Exit(1)
+		return validate(b, o, *id)
+	}
+	var failed bool
+	for _, arg := range fs.Args() {
+		if err := validate([]byte(arg), o, *id); err != nil {
+			e.errorf("%s: %v", arg, err)
+			failed = true
 		}
-		os.Stdout.Write([]byte("\n"))
 	}
+	if failed {
+		return errReported
+	}
+	return nil
+}
+
+// validate checks that in is validly encoded data, or an ID if id is set,
+// applying the flags in o.
+func validate(in []byte, o decodeFlags, id bool) error {
+	b, err := o.prepare(in, id)
+	switch {
+	case err != nil:
+	case id:
+		err = validateID(b, o.strict)
+	case o.strict:
+		err = cford32.ValidateStrict(b)
+	default:
+		err = cford32.Validate(b)
+	}
+	return o.inputError(in, err)
+}
+
+func cmdHelp(e *env, args []string) error {
+	if len(args) == 0 {
+		e.usage(e.stdout)
+		return nil
+	}
+	c, rest := lookup(args)
+	if c == nil || len(rest) > 0 {
+		e.errorf("unknown command %q", strings.Join(args, " "))
+		return errUsage
+	}
+	// Print the help on standard output.
+	e.name, e.stderr = c.name, e.stdout
+	return c.run(e, []string{"-h"})
 }
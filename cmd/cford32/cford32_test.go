@@ -0,0 +1,370 @@
+package main
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+type cliTest struct {
+	args   []string
+	stdin  string
+	stdout string
+	stderr string // regular expression
+	code   int
+}
+
+func runTests(t *testing.T, tests []cliTest) {
+	t.Helper()
+	for _, tc := range tests {
+		t.Run(strings.Join(tc.args, " "), func(t *testing.T) {
+			var stdout, stderr strings.Builder
+			code := run(tc.args, strings.NewReader(tc.stdin), &stdout, &stderr)
+			if code != tc.code {
+				t.Errorf("exit code = %d, want %d (stderr: %q)", code, tc.code, stderr.String())
+			}
+			if stdout.String() != tc.stdout {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tc.stdout)
+			}
+			if !regexp.MustCompile(tc.stderr).MatchString(stderr.String()) {
+				t.Errorf("stderr = %q, want match for %q", stderr.String(), tc.stderr)
+			}
+		})
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	runTests(t, []cliTest{
+		{args: []string{"encode"}, stdin: "foobar", stdout: "CSQPYRK1E8\n"},
+		{args: []string{"encode", "-lower"}, stdin: "foobar", stdout: "csqpyrk1e8\n"},
+		{args: []string{"encode", "-group", "4", "-w", "0"}, stdin: "foobar", stdout: "CSQP-YRK1-E8\n"},
+		{args: []string{"encode", "-w", "4"}, stdin: "foobar", stdout: "CSQP\nYRK1\nE8\n"},
+		{args: []string{"encode", "-check", "-lower", "-group", "4"}, stdin: "foobar", stdout: "csqp-yrk1-e8r\n"},
+		{args: []string{"decode"}, stdin: "csqp-yrk1\ne8\n", stdout: "foobar"},
+		{args: []string{"decode", "-check"}, stdin: "csqp-yrk1-e8r\n", stdout: "foobar"},
+		{args: []string{"decode", "-check"}, stdin: "csqp-yrk1-e8s\n", stderr: "check symbol mismatch 's'", code: 1},
+		{args: []string{"decode", "-strict"}, stdin: "csqp-yrk1-e!", stderr: "byte 11: invalid character '!'", code: 1},
+		{args: []string{"decode", "-strict"}, stdin: "CSQPYRK1E8", stdout: "foobar"},
+		{args: []string{"decode", "-strict"}, stdin: "CSQPYRK1E9", stderr: "non-canonical", code: 1},
+		{args: []string{"decode", "-lenient"}, stdin: "ＣＳＱＰ–ＹＲＫ１ Ｅ８", stdout: "foobar"},
+		{args: []string{"decode"}, stdin: "CSQ!", stderr: "invalid character '!'", code: 1},
+		{args: []string{"decode", "-strict", "-lenient"}, stderr: "mutually exclusive", code: 2},
+		{args: []string{"decode", "a", "b"}, stderr: "wrong number of arguments", code: 2},
+		{args: []string{"decode", "nonexistent-file"}, stderr: "nonexistent-file", code: 1},
+	})
+}
+
+func TestID(t *testing.T) {
+	runTests(t, []cliTest{
+		{args: []string{"id", "encode", "1", "0x10", "18446744073709551615"}, stdout: "0000001\n000000G\nZZZZZZZZZZZZZ\n"},
+		{args: []string{"id", "encode"}, stdin: "16008560262\n", stdout: "EX2YFM6\n"},
+		{args: []string{"id", "encode", "-lower", "-full", "-group", "4", "-check", "1"}, stdout: "g000-0000-0000-1d\n"},
+		{args: []string{"id", "encode", "-check", "16008560262"}, stdout: "EX2YFM6*\n"},
+		{args: []string{"id", "encode", "one", "1"}, stdout: "0000001\n", stderr: `invalid number "one"`, code: 1},
+		{args: []string{"id", "decode", "0000001", "g000-0000-0000-1", "ex2yfm6"}, stdout: "1\n1\n16008560262\n"},
+		{args: []string{"id", "decode", "-check", "EX2YFM6*"}, stdout: "16008560262\n"},
+		{args: []string{"id", "decode", "-check", "EX2YFMG*"}, stderr: `did you mean EX2YFM6\*`, code: 1},
+		{args: []string{"id", "decode", "-strict", "OOOOOO1"}, stderr: "non-canonical", code: 1},
+		{args: []string{"id", "decode", "-lenient", "ОООООО1"}, stdout: "1\n"},
+		{args: []string{"id", "decode", "000001"}, stderr: "invalid length", code: 1},
+		{args: []string{"id"}, stderr: "expected a subcommand", code: 2},
+	})
+}
+
+func TestIDLines(t *testing.T) {
+	long := strings.Repeat("x", 100<<10)
+	runTests(t, []cliTest{
+		{args: []string{"id", "encode"}, stdin: "1\n0x10\n", stdout: "0000001\n000000G\n"},
+		{args: []string{"id", "decode"}, stdin: "0000001\n  EX2YFM6 \n", stdout: "1\n  16008560262 \n"},
+		{args: []string{"id", "decode"}, stdin: "\n0000001\n\n", stdout: "\n1\n\n"},
+		{args: []string{"id", "decode"}, stdin: "0000001", stdout: "1"},
+		{
+			args:   []string{"id", "decode", "-f", "2"},
+			stdin:  "alice 0000001 x\r\n\tbob\tEX2YFM6\tz\n",
+			stdout: "alice 1 x\r\n\tbob\t16008560262\tz\n",
+		},
+		{args: []string{"id", "decode", "-f", "2", "-d", ","}, stdin: "a,0000001,b\n,EX2YFM6\n", stdout: "a,1,b\n,16008560262\n"},
+		{args: []string{"id", "encode", "-f", "1"}, stdin: "1 " + long + "\n2\n", stdout: "0000001 " + long + "\n0000002\n"},
+		{
+			args:   []string{"id", "decode"},
+			stdin:  "0000001\nbad!123\n0000002\n",
+			stdout: "1\n",
+			stderr: `^cford32 id decode: line 2: bad!123: .*byte 3: invalid character '!'\n$`,
+			code:   1,
+		},
+		{
+			args:   []string{"id", "decode", "--keep-going"},
+			stdin:  "0000001\nbad!\n0000002\n000001\n",
+			stdout: "1\nbad!\n2\n000001\n",
+			stderr: `line 2: .*\n.*line 4: 000001: .*invalid length\n$`,
+			code:   1,
+		},
+		{args: []string{"id", "decode", "-f", "2"}, stdin: "a\n", stderr: "line 1: missing field 2", code: 1},
+		{args: []string{"id", "decode", "-f", "-1"}, stderr: "must not be negative", code: 2},
+		{args: []string{"id", "decode", "-f", "1", "-d", ",,"}, stderr: "single character", code: 2},
+		{args: []string{"id", "decode", "-d", ","}, stderr: "requires -f", code: 2},
+	})
+}
+
+func TestValidate(t *testing.T) {
+	runTests(t, []cliTest{
+		{args: []string{"validate", "CSQPYRK1E8", "CSQ"}},
+		{args: []string{"validate"}, stdin: "CSQPYRK1E8\n"},
+		{args: []string{"validate", "-strict", "CSQPYRK1E8", "CSQ"}, stderr: `CSQ: .*invalid length`, code: 1},
+		{args: []string{"validate", "CSQ!", "CSQ"}, stderr: `^cford32 validate: CSQ!: .*invalid character '!'\n$`, code: 1},
+		{args: []string{"validate", "-id", "0000001", "CSQ"}, stderr: `^cford32 validate: CSQ: .*invalid length\n$`, code: 1},
+		{args: []string{"validate", "-id", "-check", "EX2YFM6*"}},
+		{args: []string{"validate", "-id", "-strict", "g000-0000-0000-1", "0000-001"}},
+		{args: []string{"validate", "-strict", "csqp-yrk1-e8"}},
+		{args: []string{"validate", "-id", "0000-00!"}, stderr: `^cford32 validate: 0000-00!: .*byte 7: invalid character '!'\n$`, code: 1},
+		{args: []string{"validate", "-strict", "csqp-yrk1-e9"}, stderr: `byte 11: non-canonical encoding '9'`, code: 1},
+		{args: []string{"validate", "-id", "-lenient", "-check", "０000-C1SS"}, stderr: `byte 10: check symbol mismatch 'S'`, code: 1},
+	})
+}
+
+func TestGen(t *testing.T) {
+	var stdout, stderr strings.Builder
+	code := run([]string{"gen", "-n", "3"}, nil, &stdout, &stderr)
+	if code != 0 || !regexp.MustCompile(`^([G-Z][0-9A-Z]{12}\n){3}$`).MatchString(stdout.String()) {
+		t.Errorf("gen = %d %q %q, want 3 full IDs", code, stdout.String(), stderr.String())
+	}
+
+	stdout.Reset()
+	code = run([]string{"gen", "-compact", "-lower", "-check"}, nil, &stdout, &stderr)
+	id := strings.TrimSpace(stdout.String())
+	if code != 0 || !regexp.MustCompile(`^[0-9a-f][0-9a-z]{6}[0-9a-z*~$=]$`).MatchString(id) {
+		t.Errorf("gen -compact = %d %q, want a compact ID with a check symbol", code, id)
+	}
+	stdoutOf(t, "id", "decode", "-check", id)
+
+	stdout.Reset()
+	code = run([]string{"gen", "-bytes", "10", "-group", "4"}, nil, &stdout, &stderr)
+	if code != 0 || !regexp.MustCompile(`^[0-9A-Z]{4}-[0-9A-Z]{4}-[0-9A-Z]{4}-[0-9A-Z]{4}\n$`).MatchString(stdout.String()) {
+		t.Errorf("gen -bytes = %d %q, want 16 characters in groups", code, stdout.String())
+	}
+}
+
+func stdoutOf(t *testing.T, args ...string) string {
+	t.Helper()
+	var stdout, stderr strings.Builder
+	if code := run(args, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("%v: exit code %d: %s", args, code, stderr.String())
+	}
+	return stdout.String()
+}
+
+func TestHelp(t *testing.T) {
+	runTests(t, []cliTest{
+		{args: []string{}, stderr: "^Usage: cford32 COMMAND", code: 2},
+		{args: []string{"nope"}, stderr: `unknown command "nope"`, code: 2},
+		{args: []string{"encode", "-nope"}, stderr: "flag provided but not defined", code: 2},
+		{args: []string{"encode", "-h"}, stderr: `(?s)^Usage: cford32 encode .*-group N`},
+	})
+	for _, args := range [][]string{{"help"}, {"-h"}, {"help", "id", "decode"}} {
+		out := stdoutOf(t, args...)
+		if !strings.HasPrefix(out, "Usage: cford32 ") {
+			t.Errorf("%v printed %q, want usage", args, out)
+		}
+	}
+}
+
+func TestInspect(t *testing.T) {
+	runTests(t, []cliTest{
+		{
+			args: []string{"inspect", "g0000000000o1"},
+			stdout: `input:        g0000000000o1
+normalized:   G000000000001
+alias:        o read as 0 at byte 11
+kind:         full id
+decimal:      1
+hex:          0x1
+binary:       0b1
+canonical:    no, 0000001
+compact form: 0000001
+`,
+		},
+		{
+			args: []string{"inspect", "EX2YFM6*"},
+			stdout: `input:      EX2YFM6*
+normalized: EX2YFM6*
+check:      * valid
+kind:       compact id
+decimal:    16008560262
+hex:        0x3ba2f3e86
+binary:     0b1110111010001011110011111010000110
+canonical:  yes
+full form:  G00000EX2YFM6
+`,
+		},
+		{
+			args:  []string{"inspect"},
+			stdin: "01ARZ3NDEKTSV4RRFFQ69G5FAV\n",
+			stdout: `input:      01ARZ3NDEKTSV4RRFFQ69G5FAV
+normalized: 01ARZ3NDEKTSV4RRFFQ69G5FAV
+kind:       ulid
+time:       2016-07-30T23:54:10.259Z
+hex:        01563e3ab5d3d6764c61efb99302bd5b
+entropy:    d6764c61efb99302bd5b
+`,
+		},
+		{
+			args: []string{"inspect", "-check", "csqp-yrk1-e8r", "CSQPYRK1E9"},
+			stdout: `input:      csqp-yrk1-e8r
+normalized: CSQPYRK1E8R
+check:      R valid
+kind:       data
+length:     6 bytes
+hex:        666f6f626172
+canonical:  yes
+
+input:        CSQPYRK1E9
+normalized:   CSQPYRK1E9
+check:        9 invalid
+did you mean: C5QPYRK1E9, CSQPYRK19E, GSQPYRK1E9
+kind:         data
+length:       5 bytes
+hex:          666f6f6261
+canonical:    no
+`,
+			stderr: `check symbol mismatch '9'`,
+			code:   1,
+		},
+		{
+			args: []string{"inspect", "-json", "EX2YFMG*", "CSQ!"},
+			stdout: `{"input":"EX2YFMG*","normalized":"EX2YFMG*","check":"invalid","suggestions":["EX2YFM6*","3X2YFMG*","EA2YFMG*"],"kind":"id","id":{"form":"compact","decimal":"16008560272","hex":"0x3ba2f3e90","binary":"0b1110111010001011110011111010010000","is_canonical":true,"canonical":"EX2YFMG","compact":"EX2YFMG","full":"G00000EX2YFMG"},"error":"illegal cford32 data at input byte 7: check symbol mismatch '*'"}` + "\n" +
+				`{"input":"CSQ!","error":"illegal cford32 data at input byte 3: invalid character '!'"}` + "\n",
+			code: 1,
+		},
+		{
+			// Without -check, a last symbol which could be a check symbol is
+			// only noted.
+			args: []string{"inspect", "B5XRSTMS"},
+			stdout: `input:      B5XRSTMS
+normalized: B5XRSTMS
+note:       last symbol would be a valid check symbol
+kind:       data
+length:     5 bytes
+hex:        597b8cea99
+canonical:  yes
+`,
+		},
+		{
+			args: []string{"inspect", "-check", "B5XRSTMS"},
+			stdout: `input:      B5XRSTMS
+normalized: B5XRSTMS
+check:      S valid
+kind:       compact id
+decimal:    12010153812
+hex:        0x2cbdc6754
+binary:     0b1011001011110111000110011101010100
+canonical:  yes
+full form:  G00000B5XRSTM
+`,
+		},
+		{args: []string{"inspect", "CSQ!"}, stderr: `CSQ!: .*invalid character '!'`, code: 1},
+		{args: []string{"inspect", "CSQP-!"}, stderr: `byte 5: invalid character '!'`, code: 1},
+	})
+}
+
+func TestConvert(t *testing.T) {
+	const uuid = "123e4567-e89b-12d3-a456-426614174000"
+	runTests(t, []cliTest{
+		{args: []string{"convert", "-from", "hex", "-to", "cford32"}, stdin: "666f6f\n626172\n", stdout: "CSQPYRK1E8\n"},
+		{args: []string{"convert", "-from", "cford32", "-to", "hex"}, stdin: "csqp-yrk1-e8\n", stdout: "666f6f626172\n"},
+		{args: []string{"convert", "-from", "cford32", "-to", "raw"}, stdin: "CSQPYRK1E8", stdout: "foobar"},
+		{args: []string{"convert", "-from", "raw", "-to", "cford32", "-lower", "-group", "4"}, stdin: "foobar", stdout: "csqp-yrk1-e8\n"},
+		{args: []string{"convert", "-from", "hex", "-to", "base64"}, stdin: "fbff", stdout: "+/8=\n"},
+		{args: []string{"convert", "-from", "hex", "-to", "base64url"}, stdin: "fbff", stdout: "-_8\n"},
+		{args: []string{"convert", "-from", "base64url", "-to", "hex"}, stdin: "-_8=", stdout: "fbff\n"},
+		{args: []string{"convert", "-from", "base64", "-to", "base32"}, stdin: "Zm9v\nYmFy\n", stdout: "MZXW6YTBOI======\n"},
+		{args: []string{"convert", "-from", "base32", "-to", "cford32"}, stdin: "MZXW6YTBOI======", stdout: "CSQPYRK1E8\n"},
+		{args: []string{"convert", "-from", "uuid", "-to", "cford32"}, stdin: uuid + "\n", stdout: "28Z4ASZ8KC9D792P89K185T000\n"},
+		{args: []string{"convert", "-from", "cford32", "-to", "uuid"}, stdin: "28Z4ASZ8KC9D792P89K185T000", stdout: uuid + "\n"},
+		{args: []string{"convert", "-from", "uuid", "-to", "hex"}, stdin: "{123E4567E89B12D3A456426614174000}", stdout: "123e4567e89b12d3a456426614174000\n"},
+		{args: []string{"convert", "-from", "uuid", "-to", "hex"}, stdin: "123e4567", stderr: "invalid UUID", code: 1},
+		{args: []string{"convert", "-from", "hex", "-to", "uuid"}, stdin: "1234", stderr: "16 bytes long, got 2", code: 1},
+		{args: []string{"convert", "-from", "hex", "-to", "cford32"}, stdin: "zz", stderr: "invalid byte", code: 1},
+		{args: []string{"convert", "-from", "cford32", "-to", "hex"}, stdin: "CSQ!", stderr: "invalid character '!'", code: 1},
+		{args: []string{"convert", "-from", "xml", "-to", "hex"}, stderr: `invalid format "xml"`, code: 2},
+		{args: []string{"convert", "-to", "hex"}, stderr: `invalid format ""`, code: 2},
+	})
+}
+
+func TestScan(t *testing.T) {
+	const log = "user=EX2YFM6 request=ZZZZZZZZZZZZ9\nno ids: example, ABCDEFG, EX2YFM6X\nprefix:0000001.\n"
+	runTests(t, []cliTest{
+		{args: []string{"scan"}, stdin: log, stdout: "user=EX2YFM6 (16008560262) request=ZZZZZZZZZZZZ9 (18446744073709551593)\nno ids: example, ABCDEFG, EX2YFM6X\nprefix:0000001 (1).\n"},
+		{args: []string{"scan", "-rewrite", "-matching"}, stdin: log, stdout: "user=16008560262 request=18446744073709551593\nprefix:1.\n"},
+		{args: []string{"scan", "-loose", "-rewrite", "-matching"}, stdin: "ABCDEFG example\n", stdout: "11119540720 16016627758\n"},
+		{args: []string{"scan", "-rewrite"}, stdin: "0000OO1 ex2yfm6", stdout: "0000OO1 16008560262"},
+		{args: []string{"scan", "-check", "-rewrite"}, stdin: "a EX2YFM6* b EX2YFM6 c EX2YFM6~\n", stdout: "a 16008560262 b EX2YFM6 c EX2YFM6~\n"},
+		{args: []string{"scan", "-json"}, stdin: log, stdout: `{"line":1,"column":6,"token":"EX2YFM6","value":16008560262}
+{"line":1,"column":22,"token":"ZZZZZZZZZZZZ9","value":18446744073709551593}
+{"line":3,"column":8,"token":"0000001","value":1}
+`},
+		{args: []string{"scan", "nonexistent-file"}, stderr: "nonexistent-file", code: 1},
+	})
+}
+
+func TestJSON(t *testing.T) {
+	const records = `{"id": 16008560262, "name": "a<b", "friends": [1, "18446744073709551615", null], "score": 1e400}
+{"id": 1, "friends": []}
+`
+	runTests(t, []cliTest{
+		{args: []string{"json", "encode", "-p", ".id", "-p", ".friends[]"}, stdin: records,
+			stdout: `{"id":"EX2YFM6","name":"a<b","friends":["0000001","ZZZZZZZZZZZZZ",null],"score":1e400}` + "\n" +
+				`{"id":"0000001","friends":[]}` + "\n"},
+		{args: []string{"json", "encode", "-p", ".friends[1]", "-lower", "-check"}, stdin: records,
+			stdout: `{"id":16008560262,"name":"a<b","friends":[1,"zzzzzzzzzzzzzq",null],"score":1e400}` + "\n" +
+				`{"id":1,"friends":[]}` + "\n"},
+		{args: []string{"json", "encode", "-p", ".", "-indent", "  "}, stdin: "1 2", stdout: "\"0000001\"\n\"0000002\"\n"},
+		{args: []string{"json", "encode", "-p", ".*"}, stdin: `{"a": 1, "b": 2}`, stdout: `{"a":"0000001","b":"0000002"}` + "\n"},
+		{args: []string{"json", "encode", "-p", `.["user-id"]`, "-indent", "  "}, stdin: `{"user-id": 1}`, stdout: "{\n  \"user-id\": \"0000001\"\n}\n"},
+		{args: []string{"json", "encode", "-p", ".name"}, stdin: records, stderr: `record 1: \.name: invalid number "a<b"`, code: 1},
+		{args: []string{"json", "encode", "-p", ".id.x"}, stdin: records, stderr: `record 1: \.id: expected an object, got a number`, code: 1},
+		{args: []string{"json", "encode", "-p", ".friends[]", "-keep-going"}, stdin: `{"friends": [1, -1]}` + "\n" + `{"friends": [2]}`,
+			stdout: `{"friends":[1,-1]}` + "\n" + `{"friends":["0000002"]}` + "\n", stderr: `record 1: \.friends\[1\]: invalid number -1`, code: 1},
+		{args: []string{"json", "encode", "-p", ".id"}, stdin: "{\"id\": 1}\n{", stdout: "{\"id\":\"0000001\"}\n", stderr: "record 2: unexpected EOF", code: 1},
+		{args: []string{"json", "decode", "-p", ".id", "-p", ".friends[]"}, stdin: `{"id": "EX2YFM6", "friends": ["zzzzzzzzzzzzz", null]}`,
+			stdout: `{"id":16008560262,"friends":[18446744073709551615,null]}` + "\n"},
+		{args: []string{"json", "decode", "-p", ".id", "-string"}, stdin: `{"id": "zzzzzzzzzzzzz"}`, stdout: `{"id":"18446744073709551615"}` + "\n"},
+		{args: []string{"json", "decode", "-p", ".id", "-check"}, stdin: `{"id": "EX2YFMG*"}`, stderr: `record 1: \.id: "EX2YFMG\*": .*check symbol mismatch.*did you mean EX2YFM6\*`, code: 1},
+		{args: []string{"json", "decode", "-p", ".id"}, stdin: `{"id": 1}`, stderr: `record 1: \.id: expected a string, got a number`, code: 1},
+		{args: []string{"json", "decode"}, stderr: "at least one -p is required", code: 2},
+		{args: []string{"json", "decode", "-p", "id"}, stderr: `invalid path "id"`, code: 2},
+		{args: []string{"json"}, stderr: "expected a subcommand: encode or decode", code: 2},
+	})
+}
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		path  string
+		steps []pathStep
+		err   string
+	}{
+		{".", nil, ""},
+		{".id", []pathStep{{kind: '.', name: "id"}}, ""},
+		{".users[].id", []pathStep{{kind: '.', name: "users"}, {kind: ']'}, {kind: '.', name: "id"}}, ""},
+		{`.a["b.c"][2].*`, []pathStep{{kind: '.', name: "a"}, {kind: '.', name: "b.c"}, {kind: '[', index: 2}, {kind: '*'}}, ""},
+		{`.["user-id"]`, []pathStep{{kind: '.', name: "user-id"}}, ""},
+		{"[0]", []pathStep{{kind: '[', index: 0}}, ""},
+		{"", nil, "must start with"},
+		{"..a", nil, "empty member name"},
+		{"[x]", nil, "invalid index"},
+		{"[1", nil, "missing ]"},
+		{`["a]`, nil, "invalid quoted member name"},
+	}
+	for _, tc := range tests {
+		steps, err := parsePath(tc.path)
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("parsePath(%q) error = %v, want %q", tc.path, err, tc.err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(steps, tc.steps) {
+			t.Errorf("parsePath(%q) = %v, %v, want %v", tc.path, steps, err, tc.steps)
+		}
+	}
+}
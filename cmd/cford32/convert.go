@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/thehowl/cford32"
+)
+
+// formats are the formats supported by the convert command.
+var formats = []string{"raw", "hex", "base64", "base64url", "base32", "cford32", "uuid"}
+
+func cmdConvert(e *env, args []string) error {
+	fs := e.flagSet("-from FORMAT -to FORMAT [OPTION...] [FILE]", `Convert FILE, or standard input, from one encoding to another, and write it to
+standard output. With no FILE, or when FILE is -, read standard input.
+
+The formats are:
+  raw        binary data
+  hex        hexadecimal
+  base64     standard base64, with padding (RFC 4648)
+  base64url  URL-safe base64, without padding; padding is ignored on input
+  base32     standard base32, with padding (RFC 4648)
+  cford32    Crockford's base32
+  uuid       a UUID, such as 123e4567-e89b-12d3-a456-426614174000
+
+All the formats but uuid are converted as a stream. Spaces and line breaks are
+ignored in the input of text formats, and text output ends with a newline.`)
+	from := fs.String("from", "", "read input in `FORMAT`")
+	to := fs.String("to", "", "write output in `FORMAT`")
+	lower := fs.Bool("lower", false, "use the lowercase variation of cford32 output")
+	group := fs.Int("group", 0, "separate groups of `N` characters of cford32 output with hyphens")
+	if err := e.parse(fs, args, 0, 1); err != nil {
+		return err
+	}
+	for _, f := range []string{*from, *to} {
+		if !isFormat(f) {
+			return usageError(fmt.Sprintf("invalid format %q: must be one of %s", f, strings.Join(formats, ", ")))
+		}
+	}
+	in, err := e.open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	r, err := decoderFor(*from, in)
+	if err != nil {
+		return err
+	}
+	if *to == "uuid" {
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		if len(b) != 16 {
+			return fmt.Errorf("a UUID must be 16 bytes long, got %d", len(b))
+		}
+		_, err = fmt.Fprintf(e.stdout, "%x-%x-%x-%x-%x\n", b[:4], b[4:6], b[6:8], b[8:10], b[10:])
+		return err
+	}
+
+	var w io.WriteCloser
+	switch *to {
+	case "raw":
+		w = nopCloser{e.stdout}
+	case "hex":
+		w = nopCloser{hex.NewEncoder(e.stdout)}
+	case "base64":
+		w = base64.NewEncoder(base64.StdEncoding, e.stdout)
+	case "base64url":
+		w = base64.NewEncoder(base64.RawURLEncoding, e.stdout)
+	case "base32":
+		w = base32.NewEncoder(base32.StdEncoding, e.stdout)
+	case "cford32":
+		w = cford32.NewEncoderOptions(e.stdout, cford32.EncoderOptions{
+			Lower:      *lower,
+			BufferSize: bufSize,
+			GroupSize:  *group,
+		})
+	}
+	if _, err := io.CopyBuffer(w, r, make([]byte, bufSize)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	if *to != "raw" {
+		_, err = io.WriteString(e.stdout, "\n")
+	}
+	return err
+}
+
+func isFormat(f string) bool {
+	for _, ff := range formats {
+		if f == ff {
+			return true
+		}
+	}
+	return false
+}
+
+// decoderFor returns a reader decoding r from the given format.
+func decoderFor(format string, r io.Reader) (io.Reader, error) {
+	if format == "raw" {
+		return r, nil
+	}
+	drop := " \t\r\n"
+	if format == "base64url" {
+		drop += "="
+	}
+	r = &filterReader{r: r, drop: drop}
+	switch format {
+	case "hex":
+		return hex.NewDecoder(r), nil
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, r), nil
+	case "base64url":
+		return base64.NewDecoder(base64.RawURLEncoding, r), nil
+	case "base32":
+		return base32.NewDecoder(base32.StdEncoding, r), nil
+	case "cford32":
+		return newDecoder(r), nil
+	case "uuid":
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		u, err := parseUUID(b)
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewReader(u[:]), nil
+	}
+	panic("invalid format " + format)
+}
+
+// parseUUID parses a UUID in its textual form, with or without hyphens,
+// braces or the "urn:uuid:" prefix.
+func parseUUID(b []byte) (u [16]byte, err error) {
+	s := strings.ToLower(string(b))
+	s = strings.TrimPrefix(s, "urn:uuid:")
+	if strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}") {
+		s = s[1 : len(s)-1]
+	}
+	if len(s) == 36 && s[8] == '-' && s[13] == '-' && s[18] == '-' && s[23] == '-' {
+		s = s[:8] + s[9:13] + s[14:18] + s[19:23] + s[24:]
+	}
+	if len(s) != 32 {
+		return u, fmt.Errorf("invalid UUID %q", b)
+	}
+	if _, err := hex.Decode(u[:], []byte(s)); err != nil {
+		return u, fmt.Errorf("invalid UUID %q", b)
+	}
+	return u, nil
+}
+
+// filterReader removes the characters in drop from the data read from r.
+type filterReader struct {
+	r    io.Reader
+	drop string
+}
+
+func (f *filterReader) Read(p []byte) (int, error) {
+	for {
+		n, err := f.r.Read(p)
+		j := 0
+		for _, c := range p[:n] {
+			if strings.IndexByte(f.drop, c) < 0 {
+				p[j] = c
+				j++
+			}
+		}
+		// Avoid returning 0, nil when all the characters were dropped.
+		if j > 0 || err != nil {
+			return j, err
+		}
+	}
+}
+
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
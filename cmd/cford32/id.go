@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"strconv"
+
+	"github.com/thehowl/cford32"
+)
+
+// encodeID returns the encoding of v, in the compact form where possible
+// unless full is set, formatted according to o.
+func encodeID(v uint64, full bool, o encodeFlags) []byte {
+	var b []byte
+	switch {
+	case full && o.lower:
+		e := cford32.PutUint64Lower(v)
+		b = e[:]
+	case full:
+		e := cford32.PutUint64(v)
+		b = e[:]
+	default:
+		b = cford32.PutCompact(v)
+		if !o.lower {
+			b = bytes.ToUpper(b)
+		}
+	}
+	b = group(b, o.group)
+	if o.check {
+		b = addCheck(b, o.lower)
+	}
+	return b
+}
+
+// group separates groups of n characters in b with hyphens.
+func group(b []byte, n int) []byte {
+	if n <= 0 || len(b) <= n {
+		return b
+	}
+	res := make([]byte, 0, len(b)+len(b)/n)
+	for i, c := range b {
+		if i > 0 && i%n == 0 {
+			res = append(res, '-')
+		}
+		res = append(res, c)
+	}
+	return res
+}
+
+// decodeID decodes the encoded ID b, applying the flags in o.
+func decodeID(in []byte, o decodeFlags) (uint64, error) {
+	b, err := o.prepare(in, true)
+	if err != nil {
+		return 0, err
+	}
+	if err := validateID(b, o.strict); err != nil {
+		return 0, o.inputError(in, err)
+	}
+	return cford32.Uint64(b)
+}
+
+// validateID validates the encoded ID b.
+func validateID(b []byte, strict bool) error {
+	if strict {
+		return cford32.ValidateUint64Strict(b)
+	}
+	return cford32.ValidateUint64(b)
+}
+
+func cmdIDEncode(e *env, args []string) error {
+	fs := e.flagSet("[OPTION...] [NUMBER...]", `Encode each NUMBER as an ID. With no NUMBER, encode the numbers read from
+standard input, one per line, copying the rest of each line unchanged.
+Numbers may be given in decimal, or in hexadecimal, octal or binary with the
+0x, 0o and 0b prefixes.
+
+Values below 2^34 use the 7-character compact form, unless -full is given;
+the others use the 13-character full form.`)
+	var o encodeFlags
+	o.register(fs)
+	var lo lineFlags
+	lo.register(fs)
+	full := fs.Bool("full", false, "always use the full form")
+	if err := e.parse(fs, args, 0, -1); err != nil {
+		return err
+	}
+	if err := lo.validate(); err != nil {
+		return err
+	}
+	return e.convert(fs, lo, func(b []byte) ([]byte, error) {
+		v, err := strconv.ParseUint(string(b), 0, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", b)
+		}
+		return encodeID(v, *full, o), nil
+	})
+}
+
+func cmdIDDecode(e *env, args []string) error {
+	fs := e.flagSet("[OPTION...] [ID...]", `Decode each ID to its uint64 value, in decimal. With no ID, decode the IDs
+read from standard input, one per line, copying the rest of each line
+unchanged. IDs may contain hyphens.`)
+	var o decodeFlags
+	o.register(fs)
+	var lo lineFlags
+	lo.register(fs)
+	if err := e.parse(fs, args, 0, -1); err != nil {
+		return err
+	}
+	if err := o.validate(); err != nil {
+		return err
+	}
+	if err := lo.validate(); err != nil {
+		return err
+	}
+	return e.convert(fs, lo, func(b []byte) ([]byte, error) {
+		v, err := decodeID(b, o)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", b, err)
+		}
+		return strconv.AppendUint(nil, v, 10), nil
+	})
+}
+
+// convert writes the result of conv for each of the arguments of fs, one per
+// line, or with no arguments, converts the lines of standard input as
+// selected by lo.
+func (e *env) convert(fs *flag.FlagSet, lo lineFlags, conv func([]byte) ([]byte, error)) error {
+	if fs.NArg() == 0 {
+		return e.convertLines(e.stdout, e.stdin, lo, conv)
+	}
+	var failed bool
+	for _, arg := range fs.Args() {
+		res, err := conv([]byte(arg))
+		if err != nil {
+			e.errorf("%v", err)
+			failed = true
+			continue
+		}
+		if _, err := fmt.Fprintf(e.stdout, "%s\n", res); err != nil {
+			return err
+		}
+	}
+	if failed {
+		return errReported
+	}
+	return nil
+}
+
+func cmdGen(e *env, args []string) error {
+	fs := e.flagSet("[OPTION...]", `Generate random IDs, using a cryptographically secure random number
+generator, and print them one per line.
+
+With -bytes, generate random data of the given size instead.`)
+	var o encodeFlags
+	o.register(fs)
+	count := fs.Int("n", 1, "generate `COUNT` values")
+	compact := fs.Bool("compact", false, "generate IDs below 2^34, which use the compact form")
+	size := fs.Int("bytes", 0, "generate `N` random bytes instead of IDs")
+	if err := e.parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	if *count < 0 || *size < 0 {
+		return usageError("-n and -bytes must not be negative")
+	}
+	for i := 0; i < *count; i++ {
+		b, err := generate(*size, *compact, o)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(e.stdout, "%s\n", b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// generate returns a random ID, below 2^34 if compact is set, or if size is
+// positive, size random bytes, encoded according to o.
+func generate(size int, compact bool, o encodeFlags) ([]byte, error) {
+	buf := make([]byte, max(size, 8))
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	if size == 0 {
+		v := binary.LittleEndian.Uint64(buf)
+		if compact {
+			v &= 1<<34 - 1
+		}
+		return encodeID(v, !compact, o), nil
+	}
+	var b []byte
+	if o.lower {
+		b = cford32.AppendEncodeLower(nil, buf[:size])
+	} else {
+		b = cford32.AppendEncode(nil, buf[:size])
+	}
+	b = group(b, o.group)
+	if o.check {
+		b = addCheck(b, o.lower)
+	}
+	return b, nil
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/thehowl/cford32"
+)
+
+func cmdInspect(e *env, args []string) error {
+	fs := e.flagSet("[OPTION...] [VALUE...]", `Describe each encoded VALUE, or with no VALUE, the value read from standard
+input.
+
+The value is normalized: separators are removed, and the aliases i I l L o O
+are replaced. Values of 7 and 13 symbols are described as IDs, values of 26
+symbols starting with 0-7 as ULIDs, and other values as data.
+
+A check symbol at the end of the value is verified if -check is given, or if
+the last symbol can only be a check symbol: one of * ~ $ = U. Otherwise, the
+whole value is described; if removing the last symbol would leave an ID or
+ULID, and that symbol is its check symbol, this is noted.`)
+	check := fs.Bool("check", false, "verify and remove the check symbol at the end of the value")
+	lenient := fs.Bool("lenient", false, "fold Unicode look-alike characters and remove spaces first")
+	asJSON := fs.Bool("json", false, "print a JSON object for each value, one per line")
+	if err := e.parse(fs, args, 0, -1); err != nil {
+		return err
+	}
+	vals, err := e.values(fs)
+	if err != nil {
+		return err
+	}
+	var failed bool
+	for i, s := range vals {
+		in := inspect(s, *check, *lenient)
+		if in.err != nil {
+			failed = true
+		}
+		switch {
+		case *asJSON:
+			b, _ := json.Marshal(in)
+			fmt.Fprintf(e.stdout, "%s\n", b)
+		case in.err != nil && in.Normalized == "":
+			e.errorf("%s: %v", s, in.err)
+		default:
+			if i > 0 {
+				fmt.Fprintln(e.stdout)
+			}
+			in.print(e.stdout)
+			if in.err != nil {
+				e.errorf("%s: %v", s, in.err)
+			}
+		}
+	}
+	if failed {
+		return errReported
+	}
+	return nil
+}
+
+// inspection is the description of a value printed by inspect.
+type inspection struct {
+	Input         string    `json:"input"`
+	Normalized    string    `json:"normalized,omitempty"`
+	Aliases       []alias   `json:"aliases,omitempty"`
+	Check         string    `json:"check,omitempty"` // "valid" or "invalid"
+	Suggestions   []string  `json:"suggestions,omitempty"`
+	PossibleCheck bool      `json:"possible_check,omitempty"` // the last symbol would be a valid check symbol
+	Kind          string    `json:"kind,omitempty"`           // "id", "ulid" or "data"
+	ID            *idInfo   `json:"id,omitempty"`
+	ULID          *ulidInfo `json:"ulid,omitempty"`
+	Data          *dataInfo `json:"data,omitempty"`
+	Error         string    `json:"error,omitempty"`
+	err           error     // the error, if any
+	checkSymbol   byte      // the check symbol, if any
+}
+
+// alias is an alias replaced during normalization.
+type alias struct {
+	Offset int    `json:"offset"` // byte offset in the input
+	From   string `json:"from"`
+	To     string `json:"to"`
+}
+
+type idInfo struct {
+	Form        string `json:"form"` // "compact" or "full"
+	Decimal     string `json:"decimal"`
+	Hex         string `json:"hex"`
+	Binary      string `json:"binary"`
+	IsCanonical bool   `json:"is_canonical"`
+	Canonical   string `json:"canonical"`
+	Compact     string `json:"compact,omitempty"`
+	Full        string `json:"full"`
+}
+
+type ulidInfo struct {
+	Time    string `json:"time"`
+	Millis  uint64 `json:"millis"`
+	Hex     string `json:"hex"`
+	Entropy string `json:"entropy"`
+}
+
+type dataInfo struct {
+	Length    int    `json:"length"`
+	Hex       string `json:"hex"`
+	Canonical bool   `json:"canonical"`
+}
+
+// inspect describes the encoded value s. Errors are recorded in the returned
+// inspection.
+func inspect(s string, check, lenient bool) *inspection {
+	in := &inspection{Input: s}
+	fail := func(err error) *inspection {
+		in.err, in.Error = err, err.Error()
+		return in
+	}
+
+	src := []byte(s)
+	if lenient {
+		var err error
+		if src, err = cford32.AppendNormalize(nil, s); err != nil {
+			return fail(err)
+		}
+	}
+	// Check symbols are only valid as the last symbol of the value.
+	last := len(bytes.TrimRight(src, "-\r\n")) - 1
+	// Validate ignores newlines, but not hyphens; replacing the latter keeps
+	// the offsets of errors in src.
+	if err := cford32.Validate(bytes.ReplaceAll(src, []byte("-"), []byte("\n"))); err != nil {
+		var derr cford32.DecodeError
+		if !errors.As(err, &derr) || derr.Offset != int64(last) || !strings.ContainsRune("*~$=Uu", rune(src[last])) {
+			return fail(err)
+		}
+		check = true
+	}
+
+	// Normalize the value, recording the aliases.
+	syms := make([]byte, 0, len(src))
+	for i, c := range src {
+		switch c {
+		case '-', '\r', '\n':
+			continue
+		case 'i', 'I', 'l', 'L':
+			in.Aliases = append(in.Aliases, alias{i, string(c), "1"})
+			c = '1'
+		case 'o', 'O':
+			in.Aliases = append(in.Aliases, alias{i, string(c), "0"})
+			c = '0'
+		}
+		if c >= 'a' && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		syms = append(syms, c)
+	}
+	in.Normalized = string(syms)
+
+	// Validate only allows check symbols in the last position; handle
+	// them before the rest of the value.
+	switch n := len(syms); {
+	case check:
+		if n == 0 {
+			return fail(errors.New("missing check symbol"))
+		}
+		in.checkSymbol, syms = syms[n-1], syms[:n-1]
+	case (n == 8 || n == 14 || n == 27) && kindOf(syms[:n-1]) != "data":
+		// About 1 in 37 values would end with a valid check symbol by
+		// chance, so only note it.
+		in.PossibleCheck = cford32.VerifyCheck(syms) == nil
+	}
+	if in.checkSymbol != 0 {
+		full := append(syms[:len(syms):len(syms)], in.checkSymbol)
+		if err := cford32.VerifyCheck(full); err != nil {
+			in.Check = "invalid"
+			for _, sg := range cford32.SuggestCorrections(full) {
+				if len(in.Suggestions) == 3 {
+					break
+				}
+				in.Suggestions = append(in.Suggestions, sg.Text)
+			}
+			in.err, in.Error = err, err.Error()
+		} else {
+			in.Check = "valid"
+		}
+	}
+
+	in.Kind = kindOf(syms)
+	switch in.Kind {
+	case "id":
+		v, err := cford32.Uint64Detailed(syms)
+		if err != nil {
+			return fail(err)
+		}
+		full := cford32.PutUint64(v)
+		in.ID = &idInfo{
+			Form:      "compact",
+			Decimal:   strconv.FormatUint(v, 10),
+			Hex:       "0x" + strconv.FormatUint(v, 16),
+			Binary:    "0b" + strconv.FormatUint(v, 2),
+			Canonical: strings.ToUpper(string(cford32.PutCompact(v))),
+			Full:      string(full[:]),
+		}
+		if len(syms) == 13 {
+			in.ID.Form = "full"
+		}
+		in.ID.IsCanonical = len(in.Aliases) == 0 && string(syms) == in.ID.Canonical
+		if v < 1<<34 {
+			in.ID.Compact = in.ID.Canonical
+		}
+	case "ulid":
+		var n big.Int
+		for _, c := range syms {
+			v := strings.IndexByte(symbols, c)
+			n.Lsh(&n, 5).Or(&n, big.NewInt(int64(v)))
+		}
+		var b [16]byte
+		n.FillBytes(b[:])
+		ms := uint64(b[0])<<40 | uint64(b[1])<<32 | uint64(b[2])<<24 |
+			uint64(b[3])<<16 | uint64(b[4])<<8 | uint64(b[5])
+		in.ULID = &ulidInfo{
+			Time:    time.UnixMilli(int64(ms)).UTC().Format(time.RFC3339Nano),
+			Millis:  ms,
+			Hex:     hex.EncodeToString(b[:]),
+			Entropy: hex.EncodeToString(b[6:]),
+		}
+	default:
+		data, err := appendDecode(nil, syms)
+		if err != nil {
+			return fail(err)
+		}
+		in.Data = &dataInfo{
+			Length:    len(data),
+			Hex:       hex.EncodeToString(data),
+			Canonical: len(in.Aliases) == 0 && cford32.ValidateStrict(syms) == nil,
+		}
+	}
+	return in
+}
+
+// symbols are the symbols of the encoding, in order of value.
+const symbols = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// kindOf returns the kind of the normalized value syms.
+func kindOf(syms []byte) string {
+	switch {
+	case len(syms) == 7 && syms[0] <= 'F',
+		len(syms) == 13 && syms[0] >= 'G':
+		return "id"
+	case len(syms) == 26 && syms[0] <= '7':
+		return "ulid"
+	}
+	return "data"
+}
+
+func (in *inspection) print(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 1, ' ', 0)
+	defer tw.Flush()
+	field := func(name, format string, args ...any) {
+		fmt.Fprintf(tw, "%s:\t%s\n", name, fmt.Sprintf(format, args...))
+	}
+
+	field("input", "%s", in.Input)
+	field("normalized", "%s", in.Normalized)
+	for _, a := range in.Aliases {
+		field("alias", "%s read as %s at byte %d", a.From, a.To, a.Offset)
+	}
+	if in.Check != "" {
+		field("check", "%c %s", in.checkSymbol, in.Check)
+		if len(in.Suggestions) > 0 {
+			field("did you mean", "%s", strings.Join(in.Suggestions, ", "))
+		}
+	}
+	if in.PossibleCheck {
+		field("note", "last symbol would be a valid check symbol")
+	}
+	if in.Kind == "" {
+		return
+	}
+	switch {
+	case in.ID != nil:
+		id := in.ID
+		field("kind", "%s id", id.Form)
+		field("decimal", "%s", id.Decimal)
+		field("hex", "%s", id.Hex)
+		field("binary", "%s", id.Binary)
+		if id.IsCanonical {
+			field("canonical", "yes")
+		} else {
+			field("canonical", "no, %s", id.Canonical)
+		}
+		if id.Form == "full" && id.Compact != "" {
+			field("compact form", "%s", id.Compact)
+		} else if id.Form == "compact" {
+			field("full form", "%s", id.Full)
+		}
+	case in.ULID != nil:
+		field("kind", "ulid")
+		field("time", "%s", in.ULID.Time)
+		field("hex", "%s", in.ULID.Hex)
+		field("entropy", "%s", in.ULID.Entropy)
+	case in.Data != nil:
+		field("kind", "data")
+		field("length", "%d bytes", in.Data.Length)
+		field("hex", "%s", in.Data.Hex)
+		field("canonical", "%s", yesNo(in.Data.Canonical))
+	}
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// values returns the arguments of fs, or with no arguments, the single value
+// read from standard input.
+func (e *env) values(fs *flag.FlagSet) ([]string, error) {
+	if fs.NArg() > 0 {
+		return fs.Args(), nil
+	}
+	b, err := io.ReadAll(e.stdin)
+	if err != nil {
+		return nil, err
+	}
+	return []string{string(bytes.TrimSpace(b))}, nil
+}
@@ -0,0 +1,374 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const jsonHelp = `
+FILE may contain a single JSON value, or a sequence of values such as NDJSON;
+each value is a record. With no FILE, or when FILE is -, read standard input.
+Records are written one per line, compacted unless -indent is given. Numbers
+are copied exactly, whatever their size.
+
+Fields are selected with -p, which may be repeated. Paths are made of:
+  .name       the member "name" of an object
+  ["name"]    the same, for names containing special characters
+  .*          all the members of an object
+  [N]         the element N of an array, counting from 0
+  []          all the elements of an array
+such as .id, .users[].id or .["user-id"]. The path . selects the whole record.
+Missing members and elements, and null values, are left unchanged.`
+
+func cmdJSONEncode(e *env, args []string) error {
+	fs := e.flagSet("-p PATH... [OPTION...] [FILE]", `Encode the numbers in the fields selected by -p in each JSON record as IDs.
+Strings containing a decimal number, often used for numbers too large for
+JavaScript, are encoded too.
+`+jsonHelp)
+	var o encodeFlags
+	o.register(fs)
+	var jo jsonFlags
+	jo.register(fs)
+	full := fs.Bool("full", false, "always use the full form")
+	if err := e.parse(fs, args, 0, 1); err != nil {
+		return err
+	}
+	if err := jo.validate(); err != nil {
+		return err
+	}
+	return e.convertJSON(fs.Arg(0), jo, func(v json.RawMessage) (json.RawMessage, error) {
+		s := string(v)
+		if v[0] == '"' {
+			if err := json.Unmarshal(v, &s); err != nil {
+				return nil, err
+			}
+		} else if !isDigit(v[0]) && v[0] != '-' {
+			return nil, fmt.Errorf("expected a number, got %s", kindOfJSON(v))
+		}
+		n, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s", v)
+		}
+		return appendJSONString(nil, string(encodeID(n, *full, o))), nil
+	})
+}
+
+func cmdJSONDecode(e *env, args []string) error {
+	fs := e.flagSet("-p PATH... [OPTION...] [FILE]", `Decode the IDs in the fields selected by -p in each JSON record to numbers.
+Numbers of 2^53 and above lose precision in JavaScript and other languages
+reading JSON numbers as floating point; -string writes them as strings.
+`+jsonHelp)
+	var o decodeFlags
+	o.register(fs)
+	var jo jsonFlags
+	jo.register(fs)
+	asString := fs.Bool("string", false, "write the decoded numbers as strings")
+	if err := e.parse(fs, args, 0, 1); err != nil {
+		return err
+	}
+	if err := o.validate(); err != nil {
+		return err
+	}
+	if err := jo.validate(); err != nil {
+		return err
+	}
+	return e.convertJSON(fs.Arg(0), jo, func(v json.RawMessage) (json.RawMessage, error) {
+		if v[0] != '"' {
+			return nil, fmt.Errorf("expected a string, got %s", kindOfJSON(v))
+		}
+		var s string
+		if err := json.Unmarshal(v, &s); err != nil {
+			return nil, err
+		}
+		n, err := decodeID([]byte(s), o)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", v, err)
+		}
+		res := strconv.AppendUint(nil, n, 10)
+		if *asString {
+			res = strconv.AppendQuote(nil, string(res))
+		}
+		return res, nil
+	})
+}
+
+// jsonFlags are the flags shared by the json commands.
+type jsonFlags struct {
+	paths     pathList
+	indent    string
+	keepGoing bool
+}
+
+func (o *jsonFlags) register(fs *flag.FlagSet) {
+	fs.Var(&o.paths, "p", "convert the fields selected by `PATH`")
+	fs.StringVar(&o.indent, "indent", "", "indent the records with `STRING`, such as two spaces")
+	fs.BoolVar(&o.keepGoing, "keep-going", false, "report invalid records and copy them unchanged, instead of stopping")
+}
+
+func (o *jsonFlags) validate() error {
+	if len(o.paths) == 0 {
+		return usageError("at least one -p is required")
+	}
+	return nil
+}
+
+// pathList is a list of paths, given as repeated flags.
+type pathList [][]pathStep
+
+func (p *pathList) String() string { return "" }
+
+func (p *pathList) Set(s string) error {
+	steps, err := parsePath(s)
+	if err != nil {
+		return err
+	}
+	*p = append(*p, steps)
+	return nil
+}
+
+// pathStep is a step of a path selecting JSON fields.
+type pathStep struct {
+	kind  byte // '.' for a member, '*' for all members, '[' for an element, ']' for all elements
+	name  string
+	index int
+}
+
+// parsePath parses the path s, as documented in jsonHelp.
+func parsePath(s string) ([]pathStep, error) {
+	if s == "." {
+		return nil, nil
+	}
+	if s == "" || s[0] != '.' && s[0] != '[' {
+		return nil, fmt.Errorf("invalid path %q: must start with . or [", s)
+	}
+	var steps []pathStep
+	for rest := s; rest != ""; {
+		switch {
+		case rest == ".*" || strings.HasPrefix(rest, ".*.") || strings.HasPrefix(rest, ".*["):
+			steps = append(steps, pathStep{kind: '*'})
+			rest = rest[2:]
+		case strings.HasPrefix(rest, ".["):
+			rest = rest[1:]
+		case rest[0] == '.':
+			end := strings.IndexAny(rest[1:], ".[") + 1
+			if end == 0 {
+				end = len(rest)
+			}
+			if end == 1 {
+				return nil, fmt.Errorf("invalid path %q: empty member name", s)
+			}
+			steps = append(steps, pathStep{kind: '.', name: rest[1:end]})
+			rest = rest[end:]
+		case strings.HasPrefix(rest, "[]"):
+			steps = append(steps, pathStep{kind: ']'})
+			rest = rest[2:]
+		case strings.HasPrefix(rest, `["`):
+			q, err := strconv.QuotedPrefix(rest[1:])
+			if err != nil || !strings.HasPrefix(rest[1+len(q):], "]") {
+				return nil, fmt.Errorf("invalid path %q: invalid quoted member name", s)
+			}
+			name, _ := strconv.Unquote(q)
+			steps = append(steps, pathStep{kind: '.', name: name})
+			rest = rest[len(q)+2:]
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid path %q: missing ]", s)
+			}
+			i, err := strconv.Atoi(rest[1:end])
+			if err != nil || i < 0 {
+				return nil, fmt.Errorf("invalid path %q: invalid index %q", s, rest[1:end])
+			}
+			steps = append(steps, pathStep{kind: '[', index: i})
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("invalid path %q: unexpected %q", s, rest[0])
+		}
+	}
+	return steps, nil
+}
+
+// convertJSON converts the fields selected by o in each record read from the
+// named file, using conv, and writes the records to standard output. conv is
+// only called for values which are not null. Errors are reported with the
+// record number and the path of the field; unless o.keepGoing is set,
+// convertJSON stops at the first one.
+func (e *env) convertJSON(name string, o jsonFlags, conv func(json.RawMessage) (json.RawMessage, error)) error {
+	in, err := e.open(name)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	dec := json.NewDecoder(bufio.NewReaderSize(in, bufSize))
+	bw := bufio.NewWriterSize(e.stdout, bufSize)
+	var failed bool
+	var buf bytes.Buffer
+	for n := 1; ; n++ {
+		var rec json.RawMessage
+		if err := dec.Decode(&rec); err == io.EOF {
+			break
+		} else if err != nil {
+			bw.Flush()
+			e.errorf("record %d: %v", n, err)
+			return errReported
+		}
+		res := rec
+		for _, p := range o.paths {
+			if res, err = transform(res, p, "", conv); err != nil {
+				break
+			}
+		}
+		if err != nil {
+			e.errorf("record %d: %v", n, err)
+			if !o.keepGoing {
+				bw.Flush()
+				return errReported
+			}
+			failed = true
+			res = rec
+		}
+		buf.Reset()
+		if o.indent != "" {
+			err = json.Indent(&buf, res, "", o.indent)
+		} else {
+			err = json.Compact(&buf, res)
+		}
+		if err != nil {
+			// transform only produces valid JSON.
+			panic(err)
+		}
+		buf.WriteByte('\n')
+		if _, err := bw.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	if failed {
+		return errReported
+	}
+	return nil
+}
+
+// transform returns v with the values selected by path replaced by the result
+// of conv. loc is the location of v in the record.
+func transform(v json.RawMessage, path []pathStep, loc string, conv func(json.RawMessage) (json.RawMessage, error)) (json.RawMessage, error) {
+	v = bytes.TrimSpace(v)
+	if string(v) == "null" {
+		return v, nil
+	}
+	if len(path) == 0 {
+		res, err := conv(v)
+		if err != nil {
+			if loc == "" {
+				loc = "."
+			}
+			return nil, fmt.Errorf("%s: %w", loc, err)
+		}
+		return res, nil
+	}
+
+	step := path[0]
+	var want byte = '{'
+	if step.kind == '[' || step.kind == ']' {
+		want = '['
+	}
+	if v[0] != want {
+		if loc == "" {
+			loc = "."
+		}
+		return nil, fmt.Errorf("%s: expected %s, got %s", loc, kindOfJSON([]byte{want}), kindOfJSON(v))
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(v))
+	dec.Token() // { or [
+	res := []byte{want}
+	for i := 0; dec.More(); i++ {
+		var key string
+		if want == '{' {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key = tok.(string)
+			if i > 0 {
+				res = append(res, ',')
+			}
+			res = append(appendJSONString(res, key), ':')
+		} else if i > 0 {
+			res = append(res, ',')
+		}
+		var elem json.RawMessage
+		if err := dec.Decode(&elem); err != nil {
+			return nil, err
+		}
+		switch {
+		case step.kind == '*',
+			step.kind == ']',
+			step.kind == '.' && key == step.name,
+			step.kind == '[' && i == step.index:
+			var err error
+			if elem, err = transform(elem, path[1:], loc+stepLoc(want, key, i), conv); err != nil {
+				return nil, err
+			}
+		}
+		res = append(res, elem...)
+	}
+	if want == '{' {
+		return append(res, '}'), nil
+	}
+	return append(res, ']'), nil
+}
+
+// stepLoc returns the location of the member key, or the element i if kind is
+// '['.
+func stepLoc(kind byte, key string, i int) string {
+	if kind == '[' {
+		return "[" + strconv.Itoa(i) + "]"
+	}
+	if key != "" && !strings.ContainsAny(key, `.[]"*`) && strings.IndexFunc(key, isSpace) < 0 {
+		return "." + key
+	}
+	return "[" + string(appendJSONString(nil, key)) + "]"
+}
+
+func isSpace(r rune) bool { return r == ' ' || r == '\t' || r == '\r' || r == '\n' }
+
+// appendJSONString appends s to dst as a JSON string, without escaping HTML
+// characters.
+func appendJSONString(dst []byte, s string) []byte {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(s); err != nil {
+		panic(err)
+	}
+	return append(dst, bytes.TrimSuffix(buf.Bytes(), []byte("\n"))...)
+}
+
+// kindOfJSON returns the kind of the JSON value v, from its first byte.
+func kindOfJSON(v []byte) string {
+	switch {
+	case v[0] == '{':
+		return "an object"
+	case v[0] == '[':
+		return "an array"
+	case v[0] == '"':
+		return "a string"
+	case v[0] == 't' || v[0] == 'f':
+		return "a boolean"
+	case v[0] == 'n':
+		return "null"
+	}
+	return "a number"
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
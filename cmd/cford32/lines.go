@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+)
+
+// lineFlags select the part of each line converted by the commands working
+// on a line at a time.
+type lineFlags struct {
+	field     int
+	delim     string
+	keepGoing bool
+}
+
+func (o *lineFlags) register(fs *flag.FlagSet) {
+	fs.IntVar(&o.field, "f", 0, "convert only field `N` of each line, counting from 1 (0 converts the whole line)")
+	fs.StringVar(&o.delim, "d", "", "separate fields with the character `DELIM` instead of spaces and tabs")
+	fs.BoolVar(&o.keepGoing, "keep-going", false, "report invalid lines and copy them unchanged, instead of stopping")
+}
+
+func (o *lineFlags) validate() error {
+	switch {
+	case o.field < 0:
+		return usageError("-f must not be negative")
+	case len(o.delim) > 1:
+		return usageError("-d must be a single character")
+	case o.delim != "" && o.field == 0:
+		return usageError("-d requires -f")
+	}
+	return nil
+}
+
+// span returns the start and end of the selected field in line, which does not
+// include the line terminator. ok is false if line doesn't have the field.
+func (o *lineFlags) span(line []byte) (start, end int, ok bool) {
+	if o.field == 0 {
+		start = len(line) - len(bytes.TrimLeft(line, " \t"))
+		end = len(bytes.TrimRight(line, " \t"))
+		return start, max(start, end), true
+	}
+	if o.delim != "" {
+		for i := 1; i < o.field; i++ {
+			j := bytes.IndexByte(line[start:], o.delim[0])
+			if j < 0 {
+				return 0, 0, false
+			}
+			start += j + 1
+		}
+		end = bytes.IndexByte(line[start:], o.delim[0])
+		if end < 0 {
+			return start, len(line), true
+		}
+		return start, start + end, true
+	}
+	for i := 1; ; i++ {
+		for start < len(line) && isBlank(line[start]) {
+			start++
+		}
+		if start == len(line) {
+			return 0, 0, false
+		}
+		end = start
+		for end < len(line) && !isBlank(line[end]) {
+			end++
+		}
+		if i == o.field {
+			return start, end, true
+		}
+		start = end
+	}
+}
+
+func isBlank(c byte) bool { return c == ' ' || c == '\t' }
+
+// eachLine calls f for each line read from r, including its terminator, with
+// its 1-based line number. It stops at the first error returned by f.
+func eachLine(r io.Reader, f func(n int, line []byte) error) error {
+	br := bufio.NewReaderSize(r, bufSize)
+	var long []byte // for lines longer than the buffer
+	for n := 1; ; n++ {
+		line, err := br.ReadSlice('\n')
+		if errors.Is(err, bufio.ErrBufferFull) {
+			long = append(long[:0], line...)
+			for errors.Is(err, bufio.ErrBufferFull) {
+				line, err = br.ReadSlice('\n')
+				long = append(long, line...)
+			}
+			line = long
+		}
+		if len(line) == 0 && err != nil {
+			if err == io.EOF {
+				err = nil
+			}
+			return err
+		}
+		if ferr := f(n, line); ferr != nil {
+			return ferr
+		}
+	}
+}
+
+// convertLines reads r a line at a time, and writes each line to w, replacing
+// the field selected by o with the result of conv. Blank fields are copied
+// unchanged. Errors are reported with the line number; unless o.keepGoing is
+// set, convertLines stops at the first one.
+func (e *env) convertLines(w io.Writer, r io.Reader, o lineFlags, conv func([]byte) ([]byte, error)) error {
+	bw := bufio.NewWriterSize(w, bufSize)
+	var failed bool
+	err := eachLine(r, func(n int, line []byte) error {
+		// Separate the line terminator, which is kept as-is.
+		content := bytes.TrimRight(line, "\r\n")
+		start, end, ok := o.span(content)
+		var res []byte
+		var cerr error
+		switch {
+		case !ok:
+			cerr = fmt.Errorf("missing field %d", o.field)
+		case start < end:
+			res, cerr = conv(content[start:end])
+		default:
+			res = content[start:end]
+		}
+		if cerr != nil {
+			e.errorf("line %d: %v", n, cerr)
+			if !o.keepGoing {
+				return errReported
+			}
+			failed = true
+			_, err := bw.Write(line)
+			return err
+		}
+		bw.Write(content[:start])
+		bw.Write(res)
+		_, err := bw.Write(line[end:])
+		return err
+	})
+	if ferr := bw.Flush(); err == nil {
+		err = ferr
+	}
+	if err == nil && failed {
+		err = errReported
+	}
+	return err
+}
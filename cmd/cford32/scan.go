@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/thehowl/cford32"
+)
+
+func cmdScan(e *env, args []string) error {
+	fs := e.flagSet("[OPTION...] [FILE...]", `Scan each FILE, or standard input, for IDs, and print the text with the
+decoded value of each ID after it, as in "EX2YFM6 (16008560262)".
+With no FILE, or when FILE is -, read standard input.
+
+IDs are tokens of 7 or 13 letters and digits, delimited by other characters,
+which are valid IDs. As many words are valid IDs, tokens must also contain a
+digit and no aliases (i I l L o O), unless -loose or -check is given.`)
+	var o scanFlags
+	fs.BoolVar(&o.check, "check", false, "only match IDs followed by their check symbol")
+	fs.BoolVar(&o.loose, "loose", false, "match any token which is a valid ID")
+	rewrite := fs.Bool("rewrite", false, "replace each ID with its decoded value")
+	matching := fs.Bool("matching", false, "only print the lines containing IDs")
+	asJSON := fs.Bool("json", false, "print a JSON object for each ID found, one per line")
+	if err := e.parse(fs, args, 0, -1); err != nil {
+		return err
+	}
+	files := fs.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	bw := bufio.NewWriterSize(e.stdout, bufSize)
+	var out []byte
+	for _, name := range files {
+		f, err := e.open(name)
+		if err != nil {
+			bw.Flush()
+			return err
+		}
+		err = eachLine(f, func(n int, line []byte) error {
+			out = out[:0]
+			last, found := 0, false
+			o.scan(line, func(start, end int, v uint64) {
+				found = true
+				if *asJSON {
+					m := scanMatch{Line: n, Column: start + 1, Token: string(line[start:end]), Value: v}
+					if len(fs.Args()) > 0 {
+						m.File = name
+					}
+					b, _ := json.Marshal(m)
+					out = append(append(out, b...), '\n')
+					return
+				}
+				if *rewrite {
+					out = append(out, line[last:start]...)
+				} else {
+					out = append(out, line[last:end]...)
+					out = append(out, " ("...)
+				}
+				out = strconv.AppendUint(out, v, 10)
+				if !*rewrite {
+					out = append(out, ')')
+				}
+				last = end
+			})
+			switch {
+			case *asJSON:
+			case found || !*matching:
+				out = append(out, line[last:]...)
+			}
+			_, err := bw.Write(out)
+			return err
+		})
+		f.Close()
+		if err != nil {
+			bw.Flush()
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// scanMatch is an ID found by scan, printed with -json.
+type scanMatch struct {
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"` // 1-based byte offset in the line
+	Token  string `json:"token"`
+	Value  uint64 `json:"value"`
+}
+
+type scanFlags struct {
+	check bool
+	loose bool
+}
+
+// scan calls f with the start, end and value of each ID found in line.
+func (o *scanFlags) scan(line []byte, f func(start, end int, v uint64)) {
+	for i := 0; i < len(line); {
+		if !isAlnum(line[i]) {
+			i++
+			continue
+		}
+		start := i
+		for i < len(line) && isAlnum(line[i]) {
+			i++
+		}
+		end := i
+		// Check symbols which are not letters may end a token.
+		if o.check && end < len(line) && strings.IndexByte("*~$=", line[end]) >= 0 &&
+			(end+1 == len(line) || !isAlnum(line[end+1])) {
+			end++
+			i++
+		}
+		if v, ok := o.match(line[start:end]); ok {
+			f(start, end, v)
+		}
+	}
+}
+
+// match reports whether tok is an ID, and returns its value.
+func (o *scanFlags) match(tok []byte) (uint64, bool) {
+	if o.check {
+		if len(tok) != 8 && len(tok) != 14 || cford32.VerifyCheck(tok) != nil {
+			return 0, false
+		}
+		tok = tok[:len(tok)-1]
+	}
+	v, err := cford32.Uint64(tok)
+	if err != nil {
+		return 0, false
+	}
+	if !o.loose && !o.check &&
+		(bytes.IndexAny(tok, "0123456789") < 0 || cford32.ValidateUint64Strict(tok) != nil) {
+		return 0, false
+	}
+	return v, true
+}
+
+func isAlnum(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
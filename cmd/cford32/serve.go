@@ -0,0 +1,410 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/thehowl/cford32"
+)
+
+// Limits of the gen endpoint.
+const (
+	maxGenCount = 1000
+	maxGenBytes = 4096
+)
+
+func cmdServe(e *env, args []string) error {
+	fs := e.flagSet("[OPTION...]", `Serve a JSON API over HTTP, providing the encode, decode, id encode,
+id decode, validate and gen commands to programs which can't use the Go
+package.
+
+All the endpoints take a POST request with a JSON object, and respond with a
+JSON object:
+  /encode     {"data": BASE64} or {"text": STRING}, "lower", "group", "check"
+              -> {"encoded": STRING}
+  /decode     {"encoded": STRING}, "check", "strict", "lenient"
+              -> {"data": BASE64, "text": STRING if the data is UTF-8}
+  /id/encode  {"value": NUMBER or STRING}, "full", "lower", "group", "check"
+              -> {"id": STRING}
+  /id/decode  {"id": STRING}, "check", "strict", "lenient"
+              -> {"value": NUMBER, "decimal": STRING}
+  /validate   {"value": STRING}, "id", "check", "strict", "lenient"
+              -> {"valid": BOOL, "error": ERROR if not valid}
+  /gen        "n", "compact", "bytes", "lower", "group", "check"
+              -> {"values": [STRING...]}
+The options have the meaning of the command line options of the same name.
+
+Errors are responded with a 4xx status and {"error": ERROR}, where ERROR is
+an object with a "code", such as "invalid_char" or "check_symbol", and a
+"message". Errors about the encoded input also have the byte "offset" of the
+error, and the invalid "char" at that offset, unless it is not ASCII and
+"lenient" isn't set.`)
+	addr := fs.String("addr", "localhost:8032", "listen on `ADDRESS`")
+	maxBody := fs.Int64("max-body", 1<<20, "reject requests larger than `N` bytes")
+	if err := e.parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	if *maxBody <= 0 {
+		return usageError("-max-body must be positive")
+	}
+	ln, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{
+		Handler:           newHandler(*maxBody),
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       time.Minute,
+		WriteTimeout:      time.Minute,
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+	}()
+	e.errorf("listening on http://%s", ln.Addr())
+	if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// newHandler returns the handler of the API served by cmdServe, rejecting
+// request bodies larger than maxBody bytes.
+func newHandler(maxBody int64) http.Handler {
+	mux := http.NewServeMux()
+	handle(mux, "/encode", maxBody, serveEncode)
+	handle(mux, "/decode", maxBody, serveDecode)
+	handle(mux, "/id/encode", maxBody, serveIDEncode)
+	handle(mux, "/id/decode", maxBody, serveIDDecode)
+	handle(mux, "/validate", maxBody, serveValidate)
+	handle(mux, "/gen", maxBody, serveGen)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, &apiError{status: http.StatusNotFound, Code: "not_found", Message: "no such endpoint: " + r.URL.Path})
+	})
+	return mux
+}
+
+// handle registers an endpoint at path, which decodes the request into a Req
+// and responds with the result of f.
+func handle[Req any](mux *http.ServeMux, path string, maxBody int64, f func(*Req) (any, error)) {
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeError(w, &apiError{status: http.StatusMethodNotAllowed, Code: "method_not_allowed", Message: "method must be POST"})
+			return
+		}
+		req := new(Req)
+		if err := readRequest(http.MaxBytesReader(w, r.Body, maxBody), req); err != nil {
+			writeError(w, err)
+			return
+		}
+		res, err := f(req)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(res)
+	})
+}
+
+// readRequest decodes the JSON object read from r into req. An empty body is
+// an empty object.
+func readRequest(r io.Reader, req any) error {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	err := dec.Decode(req)
+	if err == nil && dec.More() {
+		err = errors.New("unexpected data after the request object")
+	}
+	var maxErr *http.MaxBytesError
+	switch {
+	case err == nil || err == io.EOF:
+		return nil
+	case errors.As(err, &maxErr):
+		return &apiError{
+			status:  http.StatusRequestEntityTooLarge,
+			Code:    "too_large",
+			Message: fmt.Sprintf("request larger than %d bytes", maxErr.Limit),
+		}
+	}
+	return &apiError{status: http.StatusBadRequest, Code: "bad_request", Message: "invalid request: " + err.Error()}
+}
+
+// apiError is an error responded by the API.
+type apiError struct {
+	status  int
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Offset  *int64 `json:"offset,omitempty"` // byte offset in the encoded input
+	Char    string `json:"char,omitempty"`   // invalid character at Offset
+}
+
+func (e *apiError) Error() string { return e.Message }
+
+// toAPIError returns err as an apiError. Errors which are not apiErrors are
+// about the encoded input.
+func toAPIError(err error) *apiError {
+	var aerr *apiError
+	if errors.As(err, &aerr) {
+		return aerr
+	}
+	aerr = &apiError{status: http.StatusUnprocessableEntity, Code: "invalid_input", Message: err.Error()}
+	for _, r := range []struct {
+		err  error
+		code string
+	}{
+		{cford32.ErrInvalidChar, "invalid_char"},
+		{cford32.ErrInvalidLength, "invalid_length"},
+		{cford32.ErrNonCanonical, "non_canonical"},
+		{cford32.ErrCheckSymbol, "check_symbol"},
+	} {
+		if errors.Is(err, r.err) {
+			aerr.Code = r.code
+			break
+		}
+	}
+	var derr cford32.DecodeError
+	var lerr cford32.LenientError
+	switch {
+	case errors.As(err, &lerr):
+		aerr.Offset = &lerr.Offset
+		if lerr.Rune != 0 {
+			aerr.Char = string(lerr.Rune)
+		}
+	case errors.As(err, &derr):
+		aerr.Offset = &derr.Offset
+		if aerr.Code == "invalid_char" || aerr.Code == "non_canonical" || aerr.Code == "check_symbol" {
+			// Bytes of multi-byte characters can't be shown alone.
+			if derr.Char < utf8.RuneSelf {
+				aerr.Char = string(rune(derr.Char))
+			}
+		}
+	}
+	return aerr
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	aerr := toAPIError(err)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(aerr.status)
+	json.NewEncoder(w).Encode(struct {
+		Error *apiError `json:"error"`
+	}{aerr})
+}
+
+// badRequest returns an error for a request with invalid options.
+func badRequest(format string, args ...any) error {
+	return &apiError{status: http.StatusBadRequest, Code: "bad_request", Message: fmt.Sprintf(format, args...)}
+}
+
+// The options of the requests, matching the command line flags.
+type (
+	encodeOptions struct {
+		Lower bool `json:"lower"`
+		Group int  `json:"group"`
+		Check bool `json:"check"`
+	}
+	decodeOptions struct {
+		Check   bool `json:"check"`
+		Strict  bool `json:"strict"`
+		Lenient bool `json:"lenient"`
+	}
+)
+
+func (o encodeOptions) flags() encodeFlags {
+	return encodeFlags{lower: o.Lower, group: o.Group, check: o.Check}
+}
+
+func (o decodeOptions) flags() (decodeFlags, error) {
+	f := decodeFlags{check: o.Check, strict: o.Strict, lenient: o.Lenient}
+	if err := f.validate(); err != nil {
+		return f, badRequest("%s", strings.ReplaceAll(err.Error(), "-", ""))
+	}
+	return f, nil
+}
+
+type encodeRequest struct {
+	Data []byte  `json:"data"`
+	Text *string `json:"text"`
+	encodeOptions
+}
+
+func serveEncode(req *encodeRequest) (any, error) {
+	data := req.Data
+	switch {
+	case req.Data != nil && req.Text != nil:
+		return nil, badRequest("data and text are mutually exclusive")
+	case req.Text != nil:
+		data = []byte(*req.Text)
+	}
+	var b []byte
+	if req.Lower {
+		b = cford32.AppendEncodeLower(nil, data)
+	} else {
+		b = cford32.AppendEncode(nil, data)
+	}
+	b = group(b, req.Group)
+	if req.Check {
+		b = addCheck(b, req.Lower)
+	}
+	return map[string]string{"encoded": string(b)}, nil
+}
+
+type decodeRequest struct {
+	Encoded string `json:"encoded"`
+	decodeOptions
+}
+
+type decodeResponse struct {
+	Data []byte  `json:"data"`
+	Text *string `json:"text,omitempty"`
+}
+
+func serveDecode(req *decodeRequest) (any, error) {
+	o, err := req.flags()
+	if err != nil {
+		return nil, err
+	}
+	in := []byte(req.Encoded)
+	b, err := o.prepare(in, false)
+	if err != nil {
+		return nil, err
+	}
+	if o.strict {
+		if err := cford32.ValidateStrict(b); err != nil {
+			return nil, o.inputError(in, err)
+		}
+	}
+	res := decodeResponse{Data: []byte{}}
+	if res.Data, err = appendDecode(res.Data, b); err != nil {
+		return nil, o.inputError(in, err)
+	}
+	if utf8.Valid(res.Data) {
+		s := string(res.Data)
+		res.Text = &s
+	}
+	return res, nil
+}
+
+// number is a uint64 given as a JSON number, or as a string in the syntax
+// accepted by the id encode command.
+type number uint64
+
+func (n *number) UnmarshalJSON(b []byte) error {
+	s, base := string(b), 10
+	if len(b) > 0 && b[0] == '"' {
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		base = 0
+	}
+	v, err := strconv.ParseUint(s, base, 64)
+	if err != nil {
+		return fmt.Errorf("invalid number %s", b)
+	}
+	*n = number(v)
+	return nil
+}
+
+type idEncodeRequest struct {
+	Value *number `json:"value"`
+	Full  bool    `json:"full"`
+	encodeOptions
+}
+
+func serveIDEncode(req *idEncodeRequest) (any, error) {
+	if req.Value == nil {
+		return nil, badRequest("missing value")
+	}
+	return map[string]string{"id": string(encodeID(uint64(*req.Value), req.Full, req.flags()))}, nil
+}
+
+type idDecodeRequest struct {
+	ID string `json:"id"`
+	decodeOptions
+}
+
+type idDecodeResponse struct {
+	Value   uint64 `json:"value"`
+	Decimal string `json:"decimal"` // for clients decoding numbers as floats
+}
+
+func serveIDDecode(req *idDecodeRequest) (any, error) {
+	o, err := req.flags()
+	if err != nil {
+		return nil, err
+	}
+	v, err := decodeID([]byte(req.ID), o)
+	if err != nil {
+		return nil, err
+	}
+	return idDecodeResponse{v, strconv.FormatUint(v, 10)}, nil
+}
+
+type validateRequest struct {
+	Value string `json:"value"`
+	ID    bool   `json:"id"`
+	decodeOptions
+}
+
+type validateResponse struct {
+	Valid bool      `json:"valid"`
+	Error *apiError `json:"error,omitempty"`
+}
+
+func serveValidate(req *validateRequest) (any, error) {
+	o, err := req.flags()
+	if err != nil {
+		return nil, err
+	}
+	if err := validate([]byte(req.Value), o, req.ID); err != nil {
+		return validateResponse{Error: toAPIError(err)}, nil
+	}
+	return validateResponse{Valid: true}, nil
+}
+
+type genRequest struct {
+	N       *int `json:"n"`
+	Compact bool `json:"compact"`
+	Bytes   int  `json:"bytes"`
+	encodeOptions
+}
+
+func serveGen(req *genRequest) (any, error) {
+	n := 1
+	if req.N != nil {
+		n = *req.N
+	}
+	switch {
+	case n < 0 || n > maxGenCount:
+		return nil, badRequest("n must be between 0 and %d", maxGenCount)
+	case req.Bytes < 0 || req.Bytes > maxGenBytes:
+		return nil, badRequest("bytes must be between 0 and %d", maxGenBytes)
+	}
+	values := make([]string, n)
+	for i := range values {
+		b, err := generate(req.Bytes, req.Compact, req.flags())
+		if err != nil {
+			return nil, err
+		}
+		values[i] = string(b)
+	}
+	return map[string][]string{"values": values}, nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestServe(t *testing.T) {
+	srv := httptest.NewServer(newHandler(256))
+	defer srv.Close()
+
+	tests := []struct {
+		method string // POST if empty
+		path   string
+		body   string
+		status int
+		resp   string // regular expression
+	}{
+		{path: "/encode", body: `{"text": "foobar"}`, status: 200, resp: `^{"encoded":"CSQPYRK1E8"}$`},
+		{path: "/encode", body: `{"data": "Zm9vYmFy", "lower": true, "group": 4, "check": true}`, status: 200, resp: `^{"encoded":"csqp-yrk1-e8r"}$`},
+		{path: "/encode", body: `{"data": "Zm9v", "text": "foo"}`, status: 400, resp: `"code":"bad_request","message":"data and text are mutually exclusive"`},
+		{path: "/encode", body: `{}`, status: 200, resp: `^{"encoded":""}$`},
+		{path: "/decode", body: `{"encoded": "csqp-yrk1-e8"}`, status: 200, resp: `^{"data":"Zm9vYmFy","text":"foobar"}$`},
+		{path: "/decode", body: `{"encoded": "ZZZZ"}`, status: 200, resp: `^{"data":"//8="}$`},
+		{path: "/decode", body: `{"encoded": "CSQPYRK1E8R", "check": true}`, status: 200, resp: `"text":"foobar"`},
+		{path: "/decode", body: `{"encoded": "CSQ!"}`, status: 422, resp: `^{"error":{"code":"invalid_char","message":"[^"]*","offset":3,"char":"!"}}$`},
+		{path: "/decode", body: `{"encoded": "csqp-yrk1-e!"}`, status: 422, resp: `^{"error":{"code":"invalid_char","message":"[^"]*","offset":11,"char":"!"}}$`},
+		{path: "/decode", body: `{"encoded": "CSQPYRK1E9", "strict": true}`, status: 422, resp: `"code":"non_canonical"`},
+		{path: "/decode", body: `{"encoded": "CSQP", "strict": true, "lenient": true}`, status: 400, resp: `strict and lenient are mutually exclusive`},
+		{path: "/decode", body: `{"encoded": "Ｃ\u00e9"}`, status: 422, resp: `^{"error":{"code":"invalid_char","message":"[^"]*","offset":0}}$`},
+		{path: "/decode", body: `{"encoded": "Ｃ\u00e9", "lenient": true}`, status: 422, resp: `^{"error":{"code":"invalid_char","message":"[^"]*","offset":3,"char":"é"}}$`},
+		{path: "/id/encode", body: `{"value": 16008560262}`, status: 200, resp: `^{"id":"EX2YFM6"}$`},
+		{path: "/id/encode", body: `{"value": 18446744073709551615, "lower": true}`, status: 200, resp: `^{"id":"zzzzzzzzzzzzz"}$`},
+		{path: "/id/encode", body: `{"value": "0x10", "full": true, "check": true}`, status: 200, resp: `^{"id":"G00000000000G[^"]"}$`},
+		{path: "/id/encode", body: `{"value": 18446744073709551616}`, status: 400, resp: `invalid number 18446744073709551616`},
+		{path: "/id/encode", body: `{"value": 1.5}`, status: 400, resp: `invalid number 1.5`},
+		{path: "/id/encode", body: `{}`, status: 400, resp: `missing value`},
+		{path: "/id/decode", body: `{"id": "zzzzzzzzzzzzz"}`, status: 200, resp: `^{"value":18446744073709551615,"decimal":"18446744073709551615"}$`},
+		{path: "/id/decode", body: `{"id": "EX2YFMG*", "check": true}`, status: 422, resp: `"code":"check_symbol","message":"[^"]*did you mean EX2YFM6\*`},
+		{path: "/id/decode", body: `{"id": "000001"}`, status: 422, resp: `"code":"invalid_length"`},
+		{path: "/id/decode", body: `{"id": "0000-00!"}`, status: 422, resp: `"code":"invalid_char",.*"offset":7,"char":"!"`},
+		{path: "/validate", body: `{"value": "EX2YFM6", "id": true}`, status: 200, resp: `^{"valid":true}$`},
+		{path: "/validate", body: `{"value": "EX2YFM!", "id": true}`, status: 200, resp: `^{"valid":false,"error":{"code":"invalid_char",.*"offset":6,"char":"!"}}$`},
+		{path: "/validate", body: `{"value": "CSQPYRK1E8"}`, status: 200, resp: `^{"valid":true}$`},
+		{path: "/validate", body: `{"value": "csqp-yrk1-e9", "strict": true}`, status: 200, resp: `^{"valid":false,"error":{"code":"non_canonical",.*"offset":11,"char":"9"}}$`},
+		{path: "/gen", status: 200, resp: `^{"values":\["[0-9G-Z][0-9A-Z]{12}"\]}$`},
+		{path: "/gen", body: `{"n": 2, "compact": true, "lower": true}`, status: 200, resp: `^{"values":\["[0-9a-f][0-9a-z]{6}","[0-9a-f][0-9a-z]{6}"\]}$`},
+		{path: "/gen", body: `{"bytes": 5}`, status: 200, resp: `^{"values":\["[0-9A-Z]{8}"\]}$`},
+		{path: "/gen", body: `{"n": 100000}`, status: 400, resp: `n must be between 0 and 1000`},
+		{path: "/encode", body: `{"txt": "foo"}`, status: 400, resp: `"code":"bad_request","message":"invalid request: json: unknown field \\"txt\\""`},
+		{path: "/encode", body: `{"text": "foo"} {}`, status: 400, resp: `unexpected data after the request object`},
+		{path: "/encode", body: `{"text": "` + strings.Repeat("a", 300) + `"}`, status: 413, resp: `"code":"too_large","message":"request larger than 256 bytes"`},
+		{method: "GET", path: "/encode", status: 405, resp: `"code":"method_not_allowed"`},
+		{path: "/unknown", status: 404, resp: `"code":"not_found","message":"no such endpoint: /unknown"`},
+	}
+	for _, tc := range tests {
+		if tc.method == "" {
+			tc.method = "POST"
+		}
+		t.Run(tc.path+" "+tc.body, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, srv.URL+tc.path, strings.NewReader(tc.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := srv.Client().Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.StatusCode != tc.status {
+				t.Errorf("status = %d, want %d", res.StatusCode, tc.status)
+			}
+			if ct := res.Header.Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", ct)
+			}
+			resp := strings.TrimSuffix(string(body), "\n")
+			if !regexp.MustCompile(tc.resp).MatchString(resp) {
+				t.Errorf("response = %s, want match for %q", resp, tc.resp)
+			}
+		})
+	}
+}
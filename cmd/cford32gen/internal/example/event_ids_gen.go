@@ -0,0 +1,152 @@
+// Code generated by cford32gen from event_ids.json; DO NOT EDIT.
+
+package example
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+
+	"github.com/thehowl/cford32"
+)
+
+// EventID identifies an event, and is generated from a second spec.
+//
+// EventID is encoded with the prefix "evt_", using the compact
+// encoding of cford32, after obfuscating it with eventKey.
+// Its values range from 0 to EventIDMax.
+type EventID uint64
+
+const (
+	// EventIDPrefix is the prefix of the encoded EventIDs.
+	EventIDPrefix = "evt_"
+	// EventIDMax is the largest valid EventID.
+	EventIDMax EventID = 1<<48 - 1
+)
+
+// ParseEventID parses the encoded EventID s, with its prefix. It returns a
+// cford32.DecodeError with reason cford32.ErrPrefix if s doesn't start with
+// the prefix, and cford32.ErrOverflow if the ID is larger than EventIDMax.
+func ParseEventID(s string) (EventID, error) {
+	if len(s) < len(EventIDPrefix) || s[:len(EventIDPrefix)] != EventIDPrefix {
+		return 0, cford32.DecodeError{Err: cford32.ErrPrefix}
+	}
+	v, err := cford32.Uint64Detailed([]byte(s[len(EventIDPrefix):]))
+	if err != nil {
+		if derr, ok := err.(cford32.DecodeError); ok {
+			derr.Offset += int64(len(EventIDPrefix))
+			err = derr
+		}
+		return 0, err
+	}
+	if v > uint64(EventIDMax) {
+		return 0, cford32.DecodeError{Offset: int64(len(EventIDPrefix)), Err: cford32.ErrOverflow}
+	}
+	v = cford32genEventIdsReveal(v, eventKey, 48)
+	return EventID(v), nil
+}
+
+// Validate returns an error if id is larger than EventIDMax.
+func (id EventID) Validate() error {
+	if id > EventIDMax {
+		return fmt.Errorf("invalid EventID %d: %w", uint64(id), cford32.ErrOverflow)
+	}
+	return nil
+}
+
+// String returns the encoding of id, with its prefix.
+func (id EventID) String() string {
+	b, err := id.AppendText(nil)
+	if err != nil {
+		return "EventID(" + strconv.FormatUint(uint64(id), 10) + ", invalid)"
+	}
+	return string(b)
+}
+
+// AppendText appends the encoding of id, with its prefix, to b.
+func (id EventID) AppendText(b []byte) ([]byte, error) {
+	if err := id.Validate(); err != nil {
+		return b, err
+	}
+	v := uint64(id)
+	v = cford32genEventIdsHide(v, eventKey, 48)
+	b = append(b, EventIDPrefix...)
+	return cford32.AppendCompact(v, b), nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (id EventID) MarshalText() ([]byte, error) {
+	return id.AppendText(nil)
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, as ParseEventID.
+func (id *EventID) UnmarshalText(b []byte) error {
+	v, err := ParseEventID(string(b))
+	if err != nil {
+		return err
+	}
+	*id = v
+	return nil
+}
+
+// Value implements driver.Valuer, storing id as an integer.
+func (id EventID) Value() (driver.Value, error) {
+	if err := id.Validate(); err != nil {
+		return nil, err
+	}
+	return int64(id), nil
+}
+
+// Scan implements sql.Scanner, reading IDs stored as integers, or as decimal
+// numbers in text.
+func (id *EventID) Scan(src any) error {
+	var v uint64
+	switch src := src.(type) {
+	case int64:
+		if src < 0 {
+			return fmt.Errorf("can't scan negative value %d into EventID", src)
+		}
+		v = uint64(src)
+	case []byte:
+		return id.Scan(string(src))
+	case string:
+		var err error
+		if v, err = strconv.ParseUint(src, 10, 64); err != nil {
+			return fmt.Errorf("can't scan %q into EventID: %w", src, err)
+		}
+	default:
+		return fmt.Errorf("can't scan %T into EventID", src)
+	}
+	if err := EventID(v).Validate(); err != nil {
+		return err
+	}
+	*id = EventID(v)
+	return nil
+}
+
+// cford32genEventIdsHide obfuscates the value v of the given width with key.
+// It is a bijection on [0, 2^width), reversed by cford32genEventIdsReveal.
+func cford32genEventIdsHide(v, key uint64, width uint) uint64 {
+	mask, shift := uint64(1)<<width-1, (width+1)/2
+	for i := 0; i < 2; i++ {
+		v = (v ^ key) & mask
+		v = v * 0x9e3779b97f4a7c15 & mask
+		v ^= v >> shift
+	}
+	return v
+}
+
+// cford32genEventIdsReveal returns the value obfuscated by
+// cford32genEventIdsHide.
+func cford32genEventIdsReveal(v, key uint64, width uint) uint64 {
+	mask, shift := uint64(1)<<width-1, (width+1)/2
+	for i := 0; i < 2; i++ {
+		x := v
+		for j := uint(0); j < width; j += shift {
+			x = v ^ x>>shift
+		}
+		v = x * 0xf1de83e19937733d & mask
+		v = (v ^ key) & mask
+	}
+	return v
+}
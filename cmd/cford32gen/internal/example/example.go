@@ -0,0 +1,13 @@
+// Package example contains the types generated by cford32gen from ids.json
+// and event_ids.json, to test the generated code.
+package example
+
+//go:generate go run github.com/thehowl/cford32/cmd/cford32gen -spec ids.json
+//go:generate go run github.com/thehowl/cford32/cmd/cford32gen -spec event_ids.json
+
+const (
+	orderKey = 0x5eed
+	eventKey = 0xe7e47
+)
+
+var itemKey uint64 = 0xdeadbeef
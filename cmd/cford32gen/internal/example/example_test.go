@@ -0,0 +1,120 @@
+package example
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/thehowl/cford32"
+)
+
+func TestUserID(t *testing.T) {
+	id := UserID(16008560262)
+	if s := id.String(); s != "usr_ex2yfm6" {
+		t.Errorf("String() = %q, want usr_ex2yfm6", s)
+	}
+	if got, err := ParseUserID("usr_EX2YFM6"); got != id || err != nil {
+		t.Errorf("ParseUserID(usr_EX2YFM6) = %d, %v, want %d", got, err, id)
+	}
+	_, err := ParseUserID("ex2yfm6")
+	if !errors.Is(err, cford32.ErrPrefix) {
+		t.Errorf("ParseUserID(ex2yfm6) error = %v, want ErrPrefix", err)
+	}
+	var derr cford32.DecodeError
+	_, err = ParseUserID("usr_ex2!fm6")
+	if !errors.As(err, &derr) || derr.Offset != 7 {
+		t.Errorf("ParseUserID(usr_ex2!fm6) error = %v, want invalid character at offset 7", err)
+	}
+	if _, err := UserID(1 << 63).Value(); err == nil {
+		t.Errorf("Value() of 1<<63 succeeded, want error")
+	}
+}
+
+func TestObfuscation(t *testing.T) {
+	seen := map[string]bool{}
+	for _, id := range []OrderID{0, 1, 2, 3, OrderIDMax - 1, OrderIDMax} {
+		s := id.String()
+		if len(s) != len("ord_")+13 || seen[s] {
+			t.Errorf("OrderID(%d).String() = %q, want a unique full encoding", uint64(id), s)
+		}
+		seen[s] = true
+		if got, err := ParseOrderID(s); got != id || err != nil {
+			t.Errorf("ParseOrderID(%q) = %d, %v, want %d", s, got, err, uint64(id))
+		}
+	}
+	// Consecutive IDs don't look consecutive.
+	if a, b := ItemID(1).String(), ItemID(2).String(); a[:5] == b[:5] {
+		t.Errorf("ItemID(1) = %s and ItemID(2) = %s are too similar", a, b)
+	}
+	for id := ItemID(0); id < 1000; id++ {
+		if got, err := ParseItemID(id.String()); got != id || err != nil {
+			t.Fatalf("ParseItemID(%q) = %d, %v, want %d", id.String(), got, err, uint64(id))
+		}
+	}
+	if got, err := ParseItemID(ItemIDMax.String()); got != ItemIDMax || err != nil {
+		t.Errorf("ParseItemID(ItemIDMax) = %d, %v", got, err)
+	}
+}
+
+func TestSecondSpec(t *testing.T) {
+	for _, id := range []EventID{0, 1, 2, EventIDMax} {
+		s := id.String()
+		if got, err := ParseEventID(s); got != id || err != nil {
+			t.Errorf("ParseEventID(%q) = %d, %v, want %d", s, got, err, uint64(id))
+		}
+	}
+	if a, b := EventID(1).String(), EventID(2).String(); a[len(a)-5:] == b[len(b)-5:] {
+		t.Errorf("EventID(1) = %s and EventID(2) = %s are too similar", a, b)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := OrderIDMax.Validate(); err != nil {
+		t.Errorf("OrderIDMax.Validate() = %v", err)
+	}
+	big := OrderIDMax + 1
+	if err := big.Validate(); !errors.Is(err, cford32.ErrOverflow) {
+		t.Errorf("Validate() = %v, want ErrOverflow", err)
+	}
+	if s := big.String(); s != "OrderID(1099511627776, invalid)" {
+		t.Errorf("String() = %q", s)
+	}
+	if _, err := big.MarshalText(); err == nil {
+		t.Errorf("MarshalText() succeeded, want error")
+	}
+	_, err := ParseOrderID("ord_g000100000000")
+	if !errors.Is(err, cford32.ErrOverflow) {
+		t.Errorf("ParseOrderID(2^40) error = %v, want ErrOverflow", err)
+	}
+}
+
+func TestJSONAndSQL(t *testing.T) {
+	type order struct {
+		ID   OrderID `json:"id"`
+		User UserID  `json:"user"`
+	}
+	b, err := json.Marshal(order{ID: 1, User: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got order
+	if err := json.Unmarshal(b, &got); err != nil || got != (order{ID: 1, User: 2}) {
+		t.Errorf("json.Unmarshal(%s) = %+v, %v", b, got, err)
+	}
+
+	v, err := OrderID(5).Value()
+	if v != int64(5) || err != nil {
+		t.Errorf("Value() = %v, %v, want 5", v, err)
+	}
+	var id ItemID
+	for _, src := range []any{int64(5), []byte("5"), "5"} {
+		if err := id.Scan(src); id != 5 || err != nil {
+			t.Errorf("Scan(%#v) = %d, %v, want 5", src, uint64(id), err)
+		}
+	}
+	for _, src := range []any{int64(-1), int64(1 << 34), "x", nil} {
+		if err := id.Scan(src); err == nil {
+			t.Errorf("Scan(%#v) succeeded, want error", src)
+		}
+	}
+}
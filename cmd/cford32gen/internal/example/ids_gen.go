@@ -0,0 +1,372 @@
+// Code generated by cford32gen from ids.json; DO NOT EDIT.
+
+package example
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+
+	"github.com/thehowl/cford32"
+)
+
+// UserID identifies a user.
+//
+// UserID is encoded with the prefix "usr_", using the compact
+// encoding of cford32.
+// Its values range from 0 to UserIDMax.
+type UserID uint64
+
+const (
+	// UserIDPrefix is the prefix of the encoded UserIDs.
+	UserIDPrefix = "usr_"
+	// UserIDMax is the largest valid UserID.
+	UserIDMax UserID = 1<<64 - 1
+)
+
+// ParseUserID parses the encoded UserID s, with its prefix. It returns a
+// cford32.DecodeError with reason cford32.ErrPrefix if s doesn't start with
+// the prefix, and cford32.ErrOverflow if the ID is larger than UserIDMax.
+func ParseUserID(s string) (UserID, error) {
+	if len(s) < len(UserIDPrefix) || s[:len(UserIDPrefix)] != UserIDPrefix {
+		return 0, cford32.DecodeError{Err: cford32.ErrPrefix}
+	}
+	v, err := cford32.Uint64Detailed([]byte(s[len(UserIDPrefix):]))
+	if err != nil {
+		if derr, ok := err.(cford32.DecodeError); ok {
+			derr.Offset += int64(len(UserIDPrefix))
+			err = derr
+		}
+		return 0, err
+	}
+	return UserID(v), nil
+}
+
+// Validate returns an error if id is larger than UserIDMax.
+func (id UserID) Validate() error {
+	return nil
+}
+
+// String returns the encoding of id, with its prefix.
+func (id UserID) String() string {
+	b, err := id.AppendText(nil)
+	if err != nil {
+		return "UserID(" + strconv.FormatUint(uint64(id), 10) + ", invalid)"
+	}
+	return string(b)
+}
+
+// AppendText appends the encoding of id, with its prefix, to b.
+func (id UserID) AppendText(b []byte) ([]byte, error) {
+	if err := id.Validate(); err != nil {
+		return b, err
+	}
+	v := uint64(id)
+	b = append(b, UserIDPrefix...)
+	return cford32.AppendCompact(v, b), nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (id UserID) MarshalText() ([]byte, error) {
+	return id.AppendText(nil)
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, as ParseUserID.
+func (id *UserID) UnmarshalText(b []byte) error {
+	v, err := ParseUserID(string(b))
+	if err != nil {
+		return err
+	}
+	*id = v
+	return nil
+}
+
+// Value implements driver.Valuer, storing id as an integer.
+func (id UserID) Value() (driver.Value, error) {
+	if err := id.Validate(); err != nil {
+		return nil, err
+	}
+	if id > 1<<63-1 {
+		return nil, fmt.Errorf("UserID %d too large to be stored (at least 2^63)", uint64(id))
+	}
+	return int64(id), nil
+}
+
+// Scan implements sql.Scanner, reading IDs stored as integers, or as decimal
+// numbers in text.
+func (id *UserID) Scan(src any) error {
+	var v uint64
+	switch src := src.(type) {
+	case int64:
+		if src < 0 {
+			return fmt.Errorf("can't scan negative value %d into UserID", src)
+		}
+		v = uint64(src)
+	case []byte:
+		return id.Scan(string(src))
+	case string:
+		var err error
+		if v, err = strconv.ParseUint(src, 10, 64); err != nil {
+			return fmt.Errorf("can't scan %q into UserID: %w", src, err)
+		}
+	default:
+		return fmt.Errorf("can't scan %T into UserID", src)
+	}
+	if err := UserID(v).Validate(); err != nil {
+		return err
+	}
+	*id = UserID(v)
+	return nil
+}
+
+// OrderID is encoded with the prefix "ord_", using the full
+// encoding of cford32, after obfuscating it with orderKey.
+// Its values range from 0 to OrderIDMax.
+type OrderID uint64
+
+const (
+	// OrderIDPrefix is the prefix of the encoded OrderIDs.
+	OrderIDPrefix = "ord_"
+	// OrderIDMax is the largest valid OrderID.
+	OrderIDMax OrderID = 1<<40 - 1
+)
+
+// ParseOrderID parses the encoded OrderID s, with its prefix. It returns a
+// cford32.DecodeError with reason cford32.ErrPrefix if s doesn't start with
+// the prefix, and cford32.ErrOverflow if the ID is larger than OrderIDMax.
+func ParseOrderID(s string) (OrderID, error) {
+	if len(s) < len(OrderIDPrefix) || s[:len(OrderIDPrefix)] != OrderIDPrefix {
+		return 0, cford32.DecodeError{Err: cford32.ErrPrefix}
+	}
+	v, err := cford32.Uint64Detailed([]byte(s[len(OrderIDPrefix):]))
+	if err != nil {
+		if derr, ok := err.(cford32.DecodeError); ok {
+			derr.Offset += int64(len(OrderIDPrefix))
+			err = derr
+		}
+		return 0, err
+	}
+	if v > uint64(OrderIDMax) {
+		return 0, cford32.DecodeError{Offset: int64(len(OrderIDPrefix)), Err: cford32.ErrOverflow}
+	}
+	v = cford32genIdsReveal(v, orderKey, 40)
+	return OrderID(v), nil
+}
+
+// Validate returns an error if id is larger than OrderIDMax.
+func (id OrderID) Validate() error {
+	if id > OrderIDMax {
+		return fmt.Errorf("invalid OrderID %d: %w", uint64(id), cford32.ErrOverflow)
+	}
+	return nil
+}
+
+// String returns the encoding of id, with its prefix.
+func (id OrderID) String() string {
+	b, err := id.AppendText(nil)
+	if err != nil {
+		return "OrderID(" + strconv.FormatUint(uint64(id), 10) + ", invalid)"
+	}
+	return string(b)
+}
+
+// AppendText appends the encoding of id, with its prefix, to b.
+func (id OrderID) AppendText(b []byte) ([]byte, error) {
+	if err := id.Validate(); err != nil {
+		return b, err
+	}
+	v := uint64(id)
+	v = cford32genIdsHide(v, orderKey, 40)
+	b = append(b, OrderIDPrefix...)
+	e := cford32.PutUint64Lower(v)
+	return append(b, e[:]...), nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (id OrderID) MarshalText() ([]byte, error) {
+	return id.AppendText(nil)
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, as ParseOrderID.
+func (id *OrderID) UnmarshalText(b []byte) error {
+	v, err := ParseOrderID(string(b))
+	if err != nil {
+		return err
+	}
+	*id = v
+	return nil
+}
+
+// Value implements driver.Valuer, storing id as an integer.
+func (id OrderID) Value() (driver.Value, error) {
+	if err := id.Validate(); err != nil {
+		return nil, err
+	}
+	return int64(id), nil
+}
+
+// Scan implements sql.Scanner, reading IDs stored as integers, or as decimal
+// numbers in text.
+func (id *OrderID) Scan(src any) error {
+	var v uint64
+	switch src := src.(type) {
+	case int64:
+		if src < 0 {
+			return fmt.Errorf("can't scan negative value %d into OrderID", src)
+		}
+		v = uint64(src)
+	case []byte:
+		return id.Scan(string(src))
+	case string:
+		var err error
+		if v, err = strconv.ParseUint(src, 10, 64); err != nil {
+			return fmt.Errorf("can't scan %q into OrderID: %w", src, err)
+		}
+	default:
+		return fmt.Errorf("can't scan %T into OrderID", src)
+	}
+	if err := OrderID(v).Validate(); err != nil {
+		return err
+	}
+	*id = OrderID(v)
+	return nil
+}
+
+// ItemID is encoded using the compact
+// encoding of cford32, after obfuscating it with itemKey.
+// Its values range from 0 to ItemIDMax.
+type ItemID uint64
+
+const (
+	// ItemIDPrefix is the prefix of the encoded ItemIDs.
+	ItemIDPrefix = ""
+	// ItemIDMax is the largest valid ItemID.
+	ItemIDMax ItemID = 1<<34 - 1
+)
+
+// ParseItemID parses the encoded ItemID s, with its prefix. It returns a
+// cford32.DecodeError with reason cford32.ErrPrefix if s doesn't start with
+// the prefix, and cford32.ErrOverflow if the ID is larger than ItemIDMax.
+func ParseItemID(s string) (ItemID, error) {
+	if len(s) < len(ItemIDPrefix) || s[:len(ItemIDPrefix)] != ItemIDPrefix {
+		return 0, cford32.DecodeError{Err: cford32.ErrPrefix}
+	}
+	v, err := cford32.Uint64Detailed([]byte(s[len(ItemIDPrefix):]))
+	if err != nil {
+		if derr, ok := err.(cford32.DecodeError); ok {
+			derr.Offset += int64(len(ItemIDPrefix))
+			err = derr
+		}
+		return 0, err
+	}
+	if v > uint64(ItemIDMax) {
+		return 0, cford32.DecodeError{Offset: int64(len(ItemIDPrefix)), Err: cford32.ErrOverflow}
+	}
+	v = cford32genIdsReveal(v, itemKey, 34)
+	return ItemID(v), nil
+}
+
+// Validate returns an error if id is larger than ItemIDMax.
+func (id ItemID) Validate() error {
+	if id > ItemIDMax {
+		return fmt.Errorf("invalid ItemID %d: %w", uint64(id), cford32.ErrOverflow)
+	}
+	return nil
+}
+
+// String returns the encoding of id, with its prefix.
+func (id ItemID) String() string {
+	b, err := id.AppendText(nil)
+	if err != nil {
+		return "ItemID(" + strconv.FormatUint(uint64(id), 10) + ", invalid)"
+	}
+	return string(b)
+}
+
+// AppendText appends the encoding of id, with its prefix, to b.
+func (id ItemID) AppendText(b []byte) ([]byte, error) {
+	if err := id.Validate(); err != nil {
+		return b, err
+	}
+	v := uint64(id)
+	v = cford32genIdsHide(v, itemKey, 34)
+	b = append(b, ItemIDPrefix...)
+	return cford32.AppendCompact(v, b), nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (id ItemID) MarshalText() ([]byte, error) {
+	return id.AppendText(nil)
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, as ParseItemID.
+func (id *ItemID) UnmarshalText(b []byte) error {
+	v, err := ParseItemID(string(b))
+	if err != nil {
+		return err
+	}
+	*id = v
+	return nil
+}
+
+// Value implements driver.Valuer, storing id as an integer.
+func (id ItemID) Value() (driver.Value, error) {
+	if err := id.Validate(); err != nil {
+		return nil, err
+	}
+	return int64(id), nil
+}
+
+// Scan implements sql.Scanner, reading IDs stored as integers, or as decimal
+// numbers in text.
+func (id *ItemID) Scan(src any) error {
+	var v uint64
+	switch src := src.(type) {
+	case int64:
+		if src < 0 {
+			return fmt.Errorf("can't scan negative value %d into ItemID", src)
+		}
+		v = uint64(src)
+	case []byte:
+		return id.Scan(string(src))
+	case string:
+		var err error
+		if v, err = strconv.ParseUint(src, 10, 64); err != nil {
+			return fmt.Errorf("can't scan %q into ItemID: %w", src, err)
+		}
+	default:
+		return fmt.Errorf("can't scan %T into ItemID", src)
+	}
+	if err := ItemID(v).Validate(); err != nil {
+		return err
+	}
+	*id = ItemID(v)
+	return nil
+}
+
+// cford32genIdsHide obfuscates the value v of the given width with key.
+// It is a bijection on [0, 2^width), reversed by cford32genIdsReveal.
+func cford32genIdsHide(v, key uint64, width uint) uint64 {
+	mask, shift := uint64(1)<<width-1, (width+1)/2
+	for i := 0; i < 2; i++ {
+		v = (v ^ key) & mask
+		v = v * 0x9e3779b97f4a7c15 & mask
+		v ^= v >> shift
+	}
+	return v
+}
+
+// cford32genIdsReveal returns the value obfuscated by
+// cford32genIdsHide.
+func cford32genIdsReveal(v, key uint64, width uint) uint64 {
+	mask, shift := uint64(1)<<width-1, (width+1)/2
+	for i := 0; i < 2; i++ {
+		x := v
+		for j := uint(0); j < width; j += shift {
+			x = v ^ x>>shift
+		}
+		v = x * 0xf1de83e19937733d & mask
+		v = (v ^ key) & mask
+	}
+	return v
+}
@@ -0,0 +1,414 @@
+// Command cford32gen generates Go types for the IDs of entities, encoded with
+// the cford32 uint64 encodings.
+//
+// Each type is a named uint64 with its own prefix and width, and methods for
+// parsing, formatting, text marshaling (and so JSON), SQL and validation.
+// Unlike [cford32.ID], the generated types can have per-type constants, and
+// obfuscate their values with a key.
+//
+// cford32gen is meant to be run by go generate:
+//
+//	//go:generate go run github.com/thehowl/cford32/cmd/cford32gen -spec ids.json
+//
+// The spec is a JSON file listing the types:
+//
+//	{
+//		"types": [
+//			{"name": "UserID", "prefix": "usr_"},
+//			{"name": "OrderID", "prefix": "ord_", "width": 40, "form": "full", "key": "orderKey"}
+//		]
+//	}
+//
+// The fields of each type are:
+//
+//	name    the name of the type
+//	prefix  the prefix of the encoded IDs, required when parsing them
+//	width   the number of bits of the IDs, from 1 to 64 (default 64)
+//	form    "compact" (default) or "full", the encoding used by AppendCompact
+//	        or PutUint64Lower
+//	key     the name of a uint64 constant or variable of the package, used to
+//	        obfuscate the IDs, so that consecutive IDs look unrelated when
+//	        encoded
+//	doc     the documentation of the type, added to the generated comment
+//
+// The obfuscation is a bijection on the IDs of the given width, which is not
+// cryptographically secure: it hides the order and the number of IDs from
+// casual users, but not from someone collecting many of them.
+//
+// The generated code is written to the file given by -o, by default the name
+// of the spec with the suffix "_gen.go", in the package given by -pkg, by
+// default the package running go generate. Several specs can be generated in
+// the same package, as long as the names of their types differ.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+const usage = `Usage: cford32gen -spec FILE [-o FILE] [-pkg NAME]
+
+cford32gen generates Go types for the IDs of entities from a JSON spec.
+Run 'go doc github.com/thehowl/cford32/cmd/cford32gen' for the format of the
+spec.
+
+Options:
+`
+
+func main() {
+	fs := flag.NewFlagSet("cford32gen", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage)
+		fs.PrintDefaults()
+	}
+	specFile := fs.String("spec", "", "read the spec from `FILE`")
+	out := fs.String("o", "", "write the generated code to `FILE`")
+	pkg := fs.String("pkg", os.Getenv("GOPACKAGE"), "generate the code in package `NAME`")
+	fs.Parse(os.Args[1:])
+	if *specFile == "" || fs.NArg() > 0 || *pkg == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if *out == "" {
+		*out = strings.TrimSuffix(*specFile, filepath.Ext(*specFile)) + "_gen.go"
+	}
+	if err := run(*specFile, *out, *pkg); err != nil {
+		fmt.Fprintf(os.Stderr, "cford32gen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(specFile, out, pkg string) error {
+	b, err := os.ReadFile(specFile)
+	if err != nil {
+		return err
+	}
+	s, err := parseSpec(b)
+	if err != nil {
+		return fmt.Errorf("%s: %w", specFile, err)
+	}
+	src, err := generate(s, pkg, filepath.Base(specFile))
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(out, src, 0o666)
+}
+
+// spec is the list of types to generate.
+type spec struct {
+	Types []idType `json:"types"`
+}
+
+// idType is the spec of a generated type.
+type idType struct {
+	Name   string `json:"name"`
+	Prefix string `json:"prefix"`
+	Width  uint   `json:"width"`
+	Form   string `json:"form"`
+	Key    string `json:"key"`
+	Doc    string `json:"doc"`
+}
+
+// parseSpec parses and validates the spec in b, filling in the defaults.
+func parseSpec(b []byte) (*spec, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	var s spec
+	if err := dec.Decode(&s); err != nil {
+		return nil, err
+	}
+	if len(s.Types) == 0 {
+		return nil, errors.New("no types")
+	}
+	seen := map[string]bool{}
+	for i := range s.Types {
+		t := &s.Types[i]
+		if !token.IsIdentifier(t.Name) {
+			return nil, fmt.Errorf("type %d: invalid name %q", i+1, t.Name)
+		}
+		if seen[t.Name] {
+			return nil, fmt.Errorf("%s: duplicate type", t.Name)
+		}
+		seen[t.Name] = true
+		if strings.ContainsFunc(t.Prefix, func(r rune) bool { return r < ' ' || r == 0x7f }) {
+			return nil, fmt.Errorf("%s: prefix %q contains control characters", t.Name, t.Prefix)
+		}
+		switch {
+		case t.Width == 0:
+			t.Width = 64
+		case t.Width > 64:
+			return nil, fmt.Errorf("%s: width %d larger than 64", t.Name, t.Width)
+		}
+		switch t.Form {
+		case "":
+			t.Form = "compact"
+		case "compact", "full":
+		default:
+			return nil, fmt.Errorf("%s: invalid form %q: must be compact or full", t.Name, t.Form)
+		}
+		if t.Key != "" && !token.IsIdentifier(t.Key) {
+			return nil, fmt.Errorf("%s: invalid key %q: must be the name of a constant or variable", t.Name, t.Key)
+		}
+	}
+	return &s, nil
+}
+
+// generate returns the source of package pkg, with the types in s.
+func generate(s *spec, pkg, specName string) ([]byte, error) {
+	if !token.IsIdentifier(pkg) {
+		return nil, fmt.Errorf("invalid package name %q", pkg)
+	}
+	data := struct {
+		Package, Spec, Helpers string
+		Types                  []idType
+		Obfuscate              bool
+	}{Package: pkg, Spec: specName, Helpers: helperPrefix(specName), Types: s.Types}
+	for _, t := range s.Types {
+		data.Obfuscate = data.Obfuscate || t.Key != ""
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w", err)
+	}
+	// Check that the code parses, as format doesn't check declarations.
+	if _, err := parser.ParseFile(token.NewFileSet(), "", src, 0); err != nil {
+		return nil, fmt.Errorf("parsing generated code: %w", err)
+	}
+	return src, nil
+}
+
+// helperPrefix returns the prefix of the names of the functions generated for
+// the spec named specName, so that the code generated from several specs can
+// be in the same package: "cford32gen", followed by specName without its
+// extension in camel case, such as "cford32genOrderIds" for order_ids.json.
+func helperPrefix(specName string) string {
+	var sb strings.Builder
+	sb.WriteString("cford32gen")
+	upper := true
+	for _, r := range strings.TrimSuffix(specName, filepath.Ext(specName)) {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			upper = true
+		case upper:
+			sb.WriteRune(unicode.ToUpper(r))
+			upper = false
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// mulInverse returns the inverse of the odd number m, modulo 2^64.
+func mulInverse(m uint64) uint64 {
+	// Newton's method doubles the number of correct bits at each step.
+	inv := m
+	for i := 0; i < 5; i++ {
+		inv *= 2 - m*inv
+	}
+	return inv
+}
+
+// obfuscateMul is the multiplier used by the obfuscation.
+const obfuscateMul = 0x9e3779b97f4a7c15
+
+var tmpl = template.Must(template.New("").Funcs(template.FuncMap{
+	"max": func(t idType) string {
+		if t.Width == 64 {
+			return "1<<64 - 1"
+		}
+		return fmt.Sprintf("1<<%d - 1", t.Width)
+	},
+	"mul": func() string { return fmt.Sprintf("%#x", uint64(obfuscateMul)) },
+	"inv": func() string { return fmt.Sprintf("%#x", mulInverse(obfuscateMul)) },
+	"comment": func(s string) string {
+		return strings.ReplaceAll(strings.TrimSpace(s), "\n", "\n// ")
+	},
+}).Parse(`// Code generated by cford32gen from {{.Spec}}; DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+
+	"github.com/thehowl/cford32"
+)
+{{range .Types}}{{$n := .Name}}
+{{if .Doc}}// {{comment .Doc}}
+//
+{{end}}// {{$n}} is encoded {{if .Prefix}}with the prefix {{printf "%q" .Prefix}}, {{end}}using the {{.Form}}
+// encoding of cford32{{if .Key}}, after obfuscating it with {{.Key}}{{end}}.
+// Its values range from 0 to {{$n}}Max.
+type {{$n}} uint64
+
+const (
+	// {{$n}}Prefix is the prefix of the encoded {{$n}}s.
+	{{$n}}Prefix = {{printf "%q" .Prefix}}
+	// {{$n}}Max is the largest valid {{$n}}.
+	{{$n}}Max {{$n}} = {{max .}}
+)
+
+// Parse{{$n}} parses the encoded {{$n}} s, with its prefix. It returns a
+// cford32.DecodeError with reason cford32.ErrPrefix if s doesn't start with
+// the prefix, and cford32.ErrOverflow if the ID is larger than {{$n}}Max.
+func Parse{{$n}}(s string) ({{$n}}, error) {
+	if len(s) < len({{$n}}Prefix) || s[:len({{$n}}Prefix)] != {{$n}}Prefix {
+		return 0, cford32.DecodeError{Err: cford32.ErrPrefix}
+	}
+	v, err := cford32.Uint64Detailed([]byte(s[len({{$n}}Prefix):]))
+	if err != nil {
+		if derr, ok := err.(cford32.DecodeError); ok {
+			derr.Offset += int64(len({{$n}}Prefix))
+			err = derr
+		}
+		return 0, err
+	}
+{{- if lt .Width 64}}
+	if v > uint64({{$n}}Max) {
+		return 0, cford32.DecodeError{Offset: int64(len({{$n}}Prefix)), Err: cford32.ErrOverflow}
+	}
+{{- end}}
+{{- if .Key}}
+	v = {{$.Helpers}}Reveal(v, {{.Key}}, {{.Width}})
+{{- end}}
+	return {{$n}}(v), nil
+}
+
+// Validate returns an error if id is larger than {{$n}}Max.
+func (id {{$n}}) Validate() error {
+{{- if lt .Width 64}}
+	if id > {{$n}}Max {
+		return fmt.Errorf("invalid {{$n}} %d: %w", uint64(id), cford32.ErrOverflow)
+	}
+{{- end}}
+	return nil
+}
+
+// String returns the encoding of id, with its prefix.
+func (id {{$n}}) String() string {
+	b, err := id.AppendText(nil)
+	if err != nil {
+		return "{{$n}}(" + strconv.FormatUint(uint64(id), 10) + ", invalid)"
+	}
+	return string(b)
+}
+
+// AppendText appends the encoding of id, with its prefix, to b.
+func (id {{$n}}) AppendText(b []byte) ([]byte, error) {
+	if err := id.Validate(); err != nil {
+		return b, err
+	}
+	v := uint64(id)
+{{- if .Key}}
+	v = {{$.Helpers}}Hide(v, {{.Key}}, {{.Width}})
+{{- end}}
+	b = append(b, {{$n}}Prefix...)
+{{- if eq .Form "full"}}
+	e := cford32.PutUint64Lower(v)
+	return append(b, e[:]...), nil
+{{- else}}
+	return cford32.AppendCompact(v, b), nil
+{{- end}}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (id {{$n}}) MarshalText() ([]byte, error) {
+	return id.AppendText(nil)
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, as Parse{{$n}}.
+func (id *{{$n}}) UnmarshalText(b []byte) error {
+	v, err := Parse{{$n}}(string(b))
+	if err != nil {
+		return err
+	}
+	*id = v
+	return nil
+}
+
+// Value implements driver.Valuer, storing id as an integer.
+func (id {{$n}}) Value() (driver.Value, error) {
+	if err := id.Validate(); err != nil {
+		return nil, err
+	}
+{{- if eq .Width 64}}
+	if id > 1<<63-1 {
+		return nil, fmt.Errorf("{{$n}} %d too large to be stored (at least 2^63)", uint64(id))
+	}
+{{- end}}
+	return int64(id), nil
+}
+
+// Scan implements sql.Scanner, reading IDs stored as integers, or as decimal
+// numbers in text.
+func (id *{{$n}}) Scan(src any) error {
+	var v uint64
+	switch src := src.(type) {
+	case int64:
+		if src < 0 {
+			return fmt.Errorf("can't scan negative value %d into {{$n}}", src)
+		}
+		v = uint64(src)
+	case []byte:
+		return id.Scan(string(src))
+	case string:
+		var err error
+		if v, err = strconv.ParseUint(src, 10, 64); err != nil {
+			return fmt.Errorf("can't scan %q into {{$n}}: %w", src, err)
+		}
+	default:
+		return fmt.Errorf("can't scan %T into {{$n}}", src)
+	}
+	if err := {{$n}}(v).Validate(); err != nil {
+		return err
+	}
+	*id = {{$n}}(v)
+	return nil
+}
+{{end}}
+{{- if .Obfuscate}}
+// {{.Helpers}}Hide obfuscates the value v of the given width with key.
+// It is a bijection on [0, 2^width), reversed by {{.Helpers}}Reveal.
+func {{.Helpers}}Hide(v, key uint64, width uint) uint64 {
+	mask, shift := uint64(1)<<width-1, (width+1)/2
+	for i := 0; i < 2; i++ {
+		v = (v ^ key) & mask
+		v = v * {{mul}} & mask
+		v ^= v >> shift
+	}
+	return v
+}
+
+// {{.Helpers}}Reveal returns the value obfuscated by
+// {{.Helpers}}Hide.
+func {{.Helpers}}Reveal(v, key uint64, width uint) uint64 {
+	mask, shift := uint64(1)<<width-1, (width+1)/2
+	for i := 0; i < 2; i++ {
+		x := v
+		for j := uint(0); j < width; j += shift {
+			x = v ^ x>>shift
+		}
+		v = x * {{inv}} & mask
+		v = (v ^ key) & mask
+	}
+	return v
+}
+{{- end}}
+`))
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestGenerateExample checks that the code generated in internal/example,
+// which is tested in its package, is up to date. The package has two specs,
+// checking that they can be generated in the same package.
+func TestGenerateExample(t *testing.T) {
+	for _, name := range []string{"ids", "event_ids"} {
+		b, err := os.ReadFile("internal/example/" + name + ".json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err := parseSpec(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := generate(s, "example", name+".json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := os.ReadFile("internal/example/" + name + "_gen.go")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("generated code differs from internal/example/%s_gen.go; run go generate ./...", name)
+		}
+	}
+}
+
+func TestHelperPrefix(t *testing.T) {
+	for spec, want := range map[string]string{
+		"ids.json":         "cford32genIds",
+		"event_ids.json":   "cford32genEventIds",
+		"v2-ids.spec.json": "cford32genV2IdsSpec",
+	} {
+		if got := helperPrefix(spec); got != want {
+			t.Errorf("helperPrefix(%q) = %q, want %q", spec, got, want)
+		}
+	}
+}
+
+func TestParseSpec(t *testing.T) {
+	tests := []struct {
+		spec string
+		err  string
+	}{
+		{`{"types": [{"name": "A"}]}`, ""},
+		{`{"types": []}`, "no types"},
+		{`{"types": [{"name": "A", "size": 3}]}`, `unknown field "size"`},
+		{`{"types": [{"name": "1A"}]}`, `type 1: invalid name "1A"`},
+		{`{"types": [{"name": "A"}, {"name": "A"}]}`, "A: duplicate type"},
+		{`{"types": [{"name": "A", "prefix": "a\n"}]}`, "control characters"},
+		{`{"types": [{"name": "A", "width": 65}]}`, "width 65 larger than 64"},
+		{`{"types": [{"name": "A", "form": "short"}]}`, `invalid form "short"`},
+		{`{"types": [{"name": "A", "key": "pkg.Key"}]}`, `invalid key "pkg.Key"`},
+	}
+	for _, tc := range tests {
+		s, err := parseSpec([]byte(tc.spec))
+		switch {
+		case tc.err == "" && err != nil:
+			t.Errorf("parseSpec(%s) error = %v", tc.spec, err)
+		case tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)):
+			t.Errorf("parseSpec(%s) error = %v, want %q", tc.spec, err, tc.err)
+		case err == nil && (s.Types[0].Width != 64 || s.Types[0].Form != "compact"):
+			t.Errorf("parseSpec(%s) = %+v, want the defaults", tc.spec, s.Types[0])
+		}
+	}
+}
+
+func TestMulInverse(t *testing.T) {
+	if got := obfuscateMul * mulInverse(obfuscateMul); got != 1 {
+		t.Errorf("obfuscateMul * mulInverse(obfuscateMul) = %d, want 1", got)
+	}
+}
@@ -0,0 +1,83 @@
+package cford32
+
+import (
+	"errors"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Reasons for a [DecodeError], which can be checked using [errors.Is].
+var (
+	// ErrInvalidChar is used when the input contains a character which
+	// is not part of the encoding.
+	ErrInvalidChar = errors.New("invalid character")
+	// ErrInvalidLength is used when the input has a length which can't be
+	// produced by the encoder, such as a truncated input.
+	ErrInvalidLength = errors.New("invalid length")
+	// ErrOverflow is used when the decoded value is valid, but does not fit
+	// in the type it is decoded into.
+	ErrOverflow = errors.New("value out of range")
+	// ErrNonCanonical is used by the strict decoding functions, when the input
+	// can be decoded, but it is not in the form produced by the encoder.
+	ErrNonCanonical = errors.New("non-canonical encoding")
+	// ErrCheckSymbol is used when a check symbol does not match the value it
+	// is computed on.
+	ErrCheckSymbol = errors.New("check symbol mismatch")
+	// ErrPrefix is used when an [ID] does not start with the prefix of its
+	// entity type.
+	ErrPrefix = errors.New("missing ID prefix")
+)
+
+// DecodeError describes why an input could not be decoded. It is returned by
+// the detailed variants of the parsing functions, such as [Uint64Detailed]
+// and [DecodeDetailed], as well as by the validation and check symbol
+// functions and, wrapped in an [ArmorError], by [DecodeArmor].
+//
+// DecodeError wraps a [CorruptInputError] with the same offset, which can be
+// retrieved using [errors.As]; it also wraps Err, so that the reason can be
+// checked using [errors.Is]:
+//
+//	if errors.Is(err, cford32.ErrInvalidLength) {
+//		// ...
+//	}
+type DecodeError struct {
+	Offset int64 // byte offset in the input where the error occurred
+	Char   byte  // character at Offset, if Err is about a character
+	Err    error // the reason for the error, such as ErrInvalidChar
+}
+
+func (e DecodeError) Error() string {
+	s := CorruptInputError(e.Offset).Error() + ": " + e.Err.Error()
+	switch e.Err {
+	case ErrInvalidChar, ErrCheckSymbol:
+		s += " " + quoteByte(e.Char)
+	case ErrNonCanonical:
+		// Non-canonical values, such as NaNs, are not about a character.
+		if e.Char != 0 {
+			s += " " + quoteByte(e.Char)
+		}
+	}
+	return s
+}
+
+func (e DecodeError) Unwrap() []error {
+	return []error{e.Err, CorruptInputError(e.Offset)}
+}
+
+// corruptInput converts a DecodeError to the CorruptInputError returned by
+// the original parsing functions. Other errors are returned unchanged.
+func corruptInput(err error) error {
+	if derr, ok := err.(DecodeError); ok {
+		return CorruptInputError(derr.Offset)
+	}
+	return err
+}
+
+// quoteByte returns c quoted as a Go character literal if it is ASCII, and in
+// hexadecimal otherwise.
+func quoteByte(c byte) string {
+	if c < utf8.RuneSelf {
+		return strconv.QuoteRuneToASCII(rune(c))
+	}
+	return "0x" + strconv.FormatUint(uint64(c), 16)
+}
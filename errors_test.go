@@ -0,0 +1,103 @@
+package cford32
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// errOf returns the error of a function returning a value and an error.
+func errOf[T any](_ T, err error) error { return err }
+
+func TestDecodeError(t *testing.T) {
+	testCases := []struct {
+		err    error
+		reason error
+		offset CorruptInputError
+		msg    string
+	}{
+		{
+			Validate([]byte("CSQ!")), ErrInvalidChar, 3,
+			"illegal cford32 data at input byte 3: invalid character '!'",
+		},
+		{
+			Validate([]byte("CSQ\xff")), ErrInvalidChar, 3,
+			"illegal cford32 data at input byte 3: invalid character 0xff",
+		},
+		{
+			ValidateStrict([]byte("CSQ")), ErrInvalidLength, 2,
+			"illegal cford32 data at input byte 2: invalid length",
+		},
+		{
+			ValidateStrict([]byte("CSQo")), ErrNonCanonical, 3,
+			"illegal cford32 data at input byte 3: non-canonical encoding 'o'",
+		},
+		{
+			ValidateUint64([]byte("g000000")), ErrInvalidLength, 0,
+			"illegal cford32 data at input byte 0: invalid length",
+		},
+		{
+			errOf(Uint64Detailed([]byte("OoUoOoL"))), ErrInvalidChar, 2,
+			"illegal cford32 data at input byte 2: invalid character 'U'",
+		},
+		{
+			errOf(DecodeDetailed(make([]byte, 5), []byte("CSQ\n!"))), ErrInvalidChar, 4,
+			"illegal cford32 data at input byte 4: invalid character '!'",
+		},
+		{
+			DecodeError{Offset: 7, Char: '\n', Err: ErrCheckSymbol}, ErrCheckSymbol, 7,
+			`illegal cford32 data at input byte 7: check symbol mismatch '\n'`,
+		},
+	}
+	for _, tc := range testCases {
+		if !errors.Is(tc.err, tc.reason) {
+			t.Errorf("errors.Is(%v, %v) = false, want true", tc.err, tc.reason)
+		}
+		var cerr CorruptInputError
+		if errors.As(tc.err, &cerr) {
+			testEqual(t, "CorruptInputError of %v = %v, want %v", tc.err, cerr, tc.offset)
+		} else {
+			t.Errorf("errors.As(%v, CorruptInputError) = false, want true", tc.err)
+		}
+		var derr DecodeError
+		if errors.As(tc.err, &derr) {
+			testEqual(t, "DecodeError.Offset of %v = %v, want %v", tc.err, derr.Offset, int64(tc.offset))
+		} else {
+			t.Errorf("errors.As(%v, DecodeError) = false, want true", tc.err)
+		}
+		testEqual(t, "Error() = %q, want %q", tc.err.Error(), tc.msg)
+	}
+
+	// Errors wrapped by an ArmorError can be classified too.
+	lines := strings.Split(EncodeArmor("NOTE", []byte("hello")), "\n")
+	check := lines[1][len(lines[1])-1]
+	lines[1] = lines[1][:len(lines[1])-1] + string(checkTable[(checkValue(check)+1)%37])
+	_, _, err := DecodeArmor([]byte(strings.Join(lines, "\n")))
+	if !errors.Is(err, ErrCheckSymbol) {
+		t.Errorf("DecodeArmor with a bad check symbol gave error %v, want %v", err, ErrCheckSymbol)
+	}
+}
+
+func TestCorruptInputError(t *testing.T) {
+	// The original parsing functions return a CorruptInputError, rather than a
+	// DecodeError.
+	for _, err := range []error{
+		errOf(Uint64([]byte("OoUoOoL"))),
+		errOf(Decode(make([]byte, 5), []byte("CSQ!"))),
+		errOf(DecodeString("CSQ!")),
+		errOf(io.ReadAll(NewDecoder(strings.NewReader("CSQ!")))),
+	} {
+		if _, ok := err.(CorruptInputError); !ok {
+			t.Errorf("error %v has type %T, want CorruptInputError", err, err)
+		}
+	}
+
+	_, err := io.ReadAll(NewDecoderOptions(strings.NewReader("CSQ!"), DecoderOptions{Detailed: true}))
+	testEqual(t, "NewDecoderOptions with Detailed gave error %v, want %v", err, error(DecodeError{Offset: 3, Char: '!', Err: ErrInvalidChar}))
+
+	// Unlike the offset of a DecodeError, that of a CorruptInputError does
+	// not count newlines.
+	_, err = Decode(make([]byte, 5), []byte("CSQ\n!"))
+	testEqual(t, "Decode(%q) gave error %v, want %v", "CSQ\n!", err, error(CorruptInputError(3)))
+}
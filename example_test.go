@@ -3,6 +3,7 @@ package cford32_test
 import (
 	"fmt"
 	"math/rand"
+	"time"
 
 	"github.com/thehowl/cford32"
 )
@@ -30,3 +31,47 @@ func ExampleUint64() {
 	// Output:
 	// 16008560262
 }
+
+type Order struct{}
+
+func (Order) IDPrefix() string { return "ord_" }
+
+func ExampleID() {
+	id := cford32.ID[Order](16008560262)
+	fmt.Println(id)
+	fmt.Printf("%d\n", id)
+
+	id, err := cford32.ParseID[Order]("ord_0000001")
+	fmt.Println(uint64(id), err)
+	_, err = cford32.ParseID[Order]("0000001")
+	fmt.Println(err)
+	// Output:
+	// ord_ex2yfm6
+	// 16008560262
+	// 1 <nil>
+	// illegal cford32 data at input byte 0: missing ID prefix
+}
+
+func ExampleAppendTuple() {
+	createdAt := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	key, _ := cford32.AppendTupleLower(nil, "acme", createdAt, uint64(42))
+	fmt.Println(string(key))
+
+	fields, _ := cford32.DecodeTuple(key)
+	fmt.Println(fields...)
+	// Output:
+	// 441434d4505r000001k3h0es0000001g00000000001a
+	// acme 2024-05-06 07:08:09 +0000 UTC 42
+}
+
+func ExamplePutTime() {
+	at := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	key, _ := cford32.PutTimeLower(at, time.Millisecond)
+	fmt.Println(string(key[:]))
+
+	t, _ := cford32.Time(key[:], time.Millisecond)
+	fmt.Println(t)
+	// Output:
+	// r0001hx6bpdx8
+	// 2024-05-06 07:08:09 +0000 UTC
+}
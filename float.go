@@ -0,0 +1,79 @@
+package cford32
+
+import "math"
+
+// canonicalNaN is the NaN encoded for all NaNs by [PutFloat64]: the quiet NaN
+// with no payload.
+const canonicalNaN = 0x7ff8000000000000
+
+// PutFloat64 returns the encoding of f, using the full encoding of
+// [PutUint64]. The encodings of float64s sort like the values:
+//
+//   - negative values sort before positive ones, and -0 sorts just before +0;
+//   - all NaNs have the same encoding, which sorts after +Inf.
+//
+// The encoding is uppercase; use [PutFloat64Lower] for lowercase.
+func PutFloat64(f float64) [13]byte {
+	return PutUint64(float64Key(f))
+}
+
+// PutFloat64Lower is like [PutFloat64], but uses the lowercase variation of
+// the encoding.
+func PutFloat64Lower(f float64) [13]byte {
+	return PutUint64Lower(float64Key(f))
+}
+
+// float64Key returns a uint64 which sorts like f, flipping the sign bit of
+// positive values and all the bits of negative values.
+func float64Key(f float64) uint64 {
+	if f != f {
+		return canonicalNaN | 1<<63
+	}
+	b := math.Float64bits(f)
+	if b>>63 != 0 {
+		return ^b
+	}
+	return b | 1<<63
+}
+
+// Float64 decodes a float64 encoded by [PutFloat64].
+//
+// Float64 is strict: b must be 13 characters long, using the full encoding,
+// and the aliases i I l L o O are not allowed. NaNs must have the encoding
+// produced by PutFloat64. Inputs which fail these checks return a
+// [DecodeError] with reason [ErrInvalidChar], [ErrInvalidLength] or
+// [ErrNonCanonical]. Both cases are accepted.
+func Float64(b []byte) (float64, error) {
+	v, err := uint64Full(b)
+	if err != nil {
+		return 0, err
+	}
+	if v>>63 != 0 {
+		v &^= 1 << 63
+	} else {
+		v = ^v
+	}
+	f := math.Float64frombits(v)
+	if f != f && v != canonicalNaN {
+		return 0, DecodeError{Offset: 0, Err: ErrNonCanonical}
+	}
+	return f, nil
+}
+
+// uint64Full decodes the full encoding of a uint64, as produced by
+// [PutUint64], rejecting the compact encoding and aliases.
+func uint64Full(b []byte) (uint64, error) {
+	if len(b) != 13 {
+		return 0, DecodeError{Offset: 0, Err: ErrInvalidLength}
+	}
+	v, err := Uint64Detailed(b)
+	if err != nil {
+		return 0, err
+	}
+	for i, c := range b {
+		if isAlias(c) {
+			return 0, DecodeError{Offset: int64(i), Char: c, Err: ErrNonCanonical}
+		}
+	}
+	return v, nil
+}
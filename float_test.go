@@ -0,0 +1,71 @@
+package cford32
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestFloat64(t *testing.T) {
+	values := []float64{
+		math.Inf(-1), -math.MaxFloat64, -1e10, -1, -0.5, -math.SmallestNonzeroFloat64,
+		math.Copysign(0, -1), 0, math.SmallestNonzeroFloat64, 0.5, 1, 1e10,
+		math.MaxFloat64, math.Inf(1), math.NaN(),
+	}
+	keys := make([]string, len(values))
+	for i, f := range values {
+		enc := PutFloat64(f)
+		keys[i] = string(enc[:])
+		lower := PutFloat64Lower(f)
+		testEqual(t, "PutFloat64Lower(%v) = %s, want %s", f, string(lower[:]), strings.ToLower(keys[i]))
+
+		got, err := Float64(lower[:])
+		if err != nil {
+			t.Errorf("Float64(%s): %v", lower, err)
+			continue
+		}
+		if math.Float64bits(got) != math.Float64bits(f) && !(math.IsNaN(got) && math.IsNaN(f)) {
+			t.Errorf("Float64(%s) = %v, want %v", enc, got, f)
+		}
+	}
+	if !sort.StringsAreSorted(keys) {
+		t.Errorf("float64 encodings are not sorted: %q", keys)
+	}
+
+	nan := PutFloat64(math.Float64frombits(0xfff0000000000123))
+	testEqual(t, "PutFloat64(-NaN) = %s, want %s", string(nan[:]), keys[len(keys)-1])
+}
+
+func TestFloat64Errors(t *testing.T) {
+	nan := PutUint64(0xfff0000000000123)
+	tests := []struct {
+		src    string
+		offset int64
+		err    error
+	}{
+		{"", 0, ErrInvalidLength},
+		{"0000000", 0, ErrInvalidLength},
+		{"G0000000000000", 0, ErrInvalidLength},
+		{"0000000000000", 0, ErrInvalidLength},
+		{"G00000000000!", 12, ErrInvalidChar},
+		{"G0000000000O0", 11, ErrNonCanonical},
+		{string(nan[:]), 0, ErrNonCanonical},
+	}
+	for _, tc := range tests {
+		_, err := Float64([]byte(tc.src))
+		var derr DecodeError
+		if !errors.As(err, &derr) || derr.Offset != tc.offset || !errors.Is(err, tc.err) {
+			t.Errorf("Float64(%q) = %v, want error at %d: %v", tc.src, err, tc.offset, tc.err)
+		}
+	}
+
+	_, err := Float64(nan[:])
+	var derr DecodeError
+	if errors.As(err, &derr) && derr.Char != 0 {
+		t.Errorf("Float64(%s) error has Char %q, want none", nan[:], derr.Char)
+	}
+	testEqual(t, "Float64(non-canonical NaN) error = %q, want %q", err.Error(),
+		"illegal cford32 data at input byte 0: non-canonical encoding")
+}
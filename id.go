@@ -0,0 +1,149 @@
+package cford32
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// ID is a uint64 identifying an entity of type T, such as a row of a database
+// table, encoded using the compact encoding described in the package
+// documentation. T is only used at compile time, so that the IDs of different
+// entities can't be mixed up:
+//
+//	type User struct{ ID cford32.ID[User] }
+//	type Order struct{ ID, UserID cford32.ID[User] }
+//
+//	func FindUser(id cford32.ID[User]) (*User, error)
+//
+//	FindUser(order.ID) // compile error
+//
+// If T implements [IDPrefixer], its prefix is added to the encoded IDs, and
+// required when parsing them.
+//
+// ID implements [encoding.TextMarshaler] and [encoding.TextUnmarshaler], so
+// that it is encoded as a string in JSON and other text formats. It also
+// implements [driver.Valuer] and [sql.Scanner], storing IDs as integers in
+// databases, and [fmt.Formatter].
+type ID[T any] uint64
+
+// IDPrefixer is implemented by entity types whose [ID] has a prefix, such as
+// "usr_", when encoded.
+type IDPrefixer interface {
+	IDPrefix() string
+}
+
+// idPrefix returns the prefix of the IDs of T.
+func idPrefix[T any]() string {
+	var t T
+	if p, ok := any(t).(IDPrefixer); ok {
+		return p.IDPrefix()
+	}
+	return ""
+}
+
+// ParseID parses the encoded ID s of an entity of type T, with its prefix.
+// It returns a [DecodeError] with reason [ErrPrefix] if s does not start with
+// the prefix, and with the reasons of [Uint64Detailed] otherwise.
+func ParseID[T any](s string) (ID[T], error) {
+	prefix := idPrefix[T]()
+	if len(s) < len(prefix) || s[:len(prefix)] != prefix {
+		return 0, DecodeError{Err: ErrPrefix}
+	}
+	v, err := Uint64Detailed([]byte(s[len(prefix):]))
+	if err != nil {
+		if derr, ok := err.(DecodeError); ok {
+			derr.Offset += int64(len(prefix))
+			err = derr
+		}
+		return 0, err
+	}
+	return ID[T](v), nil
+}
+
+// String returns the encoding of id, with the prefix of T.
+func (id ID[T]) String() string {
+	b, _ := id.AppendText(nil)
+	return string(b)
+}
+
+// AppendText appends the encoding of id, with the prefix of T, to b.
+func (id ID[T]) AppendText(b []byte) ([]byte, error) {
+	return AppendCompact(uint64(id), append(b, idPrefix[T]()...)), nil
+}
+
+// MarshalText returns the encoding of id, with the prefix of T.
+func (id ID[T]) MarshalText() ([]byte, error) {
+	return id.AppendText(nil)
+}
+
+// UnmarshalText parses the encoded ID b, as [ParseID].
+func (id *ID[T]) UnmarshalText(b []byte) error {
+	v, err := ParseID[T](string(b))
+	if err != nil {
+		return err
+	}
+	*id = v
+	return nil
+}
+
+// IsZero reports whether id is 0, which is used by encoders supporting the
+// omitzero option.
+func (id ID[T]) IsZero() bool { return id == 0 }
+
+// Value implements [driver.Valuer], storing id as an integer. IDs of 2^63 and
+// above can't be stored, like uint64s.
+func (id ID[T]) Value() (driver.Value, error) {
+	if id > math.MaxInt64 {
+		return nil, fmt.Errorf("cford32: ID %d too large to be stored (at least 2^63)", uint64(id))
+	}
+	return int64(id), nil
+}
+
+// Scan implements [sql.Scanner], reading IDs stored as integers, or as
+// decimal numbers in text. Use [sql.Null] for nullable columns.
+func (id *ID[T]) Scan(src any) error {
+	switch src := src.(type) {
+	case int64:
+		if src < 0 {
+			return fmt.Errorf("cford32: can't scan negative value %d into %T", src, id)
+		}
+		*id = ID[T](src)
+		return nil
+	case []byte:
+		return id.scanString(string(src))
+	case string:
+		return id.scanString(src)
+	}
+	return fmt.Errorf("cford32: can't scan %T into %T", src, id)
+}
+
+func (id *ID[T]) scanString(s string) error {
+	v, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("cford32: can't scan %q into %T: %w", s, id, err)
+	}
+	*id = ID[T](v)
+	return nil
+}
+
+// Format implements [fmt.Formatter]. The %s and %v verbs print the encoding
+// of id, %q prints it quoted, and the integer verbs, such as %d and %x, print
+// its value. %#v prints id as a Go expression.
+func (id ID[T]) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if f.Flag('#') {
+			fmt.Fprintf(f, "%T(%d)", id, uint64(id))
+			return
+		}
+		fallthrough
+	case 's', 'q':
+		fmt.Fprintf(f, fmt.FormatString(f, verb), id.String())
+	case 'd', 'b', 'o', 'O', 'x', 'X':
+		fmt.Fprintf(f, fmt.FormatString(f, verb), uint64(id))
+	default:
+		fmt.Fprintf(f, "%%!%c(%T=%s)", verb, id, id.String())
+	}
+}
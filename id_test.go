@@ -0,0 +1,116 @@
+package cford32
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+type idUser struct{}
+
+type idOrder struct{}
+
+func (idOrder) IDPrefix() string { return "ord_" }
+
+func TestID(t *testing.T) {
+	u := ID[idUser](16008560262)
+	o := ID[idOrder](1)
+	testEqual(t, "ID[idUser].String() = %q, want %q", u.String(), "ex2yfm6")
+	testEqual(t, "ID[idOrder].String() = %q, want %q", o.String(), "ord_0000001")
+	testEqual(t, "ID[idOrder](1<<40).String() = %q, want %q", ID[idOrder](1<<40).String(), "ord_g000100000000")
+
+	tests := []struct {
+		format string
+		arg    any
+		want   string
+	}{
+		{"%s", u, "ex2yfm6"},
+		{"%v", o, "ord_0000001"},
+		{"%q", o, `"ord_0000001"`},
+		{"%10s|", u, "   ex2yfm6|"},
+		{"%d", u, "16008560262"},
+		{"%x", u, "3ba2f3e86"},
+		{"%#v", o, "cford32.ID[github.com/thehowl/cford32.idOrder](1)"},
+		{"%t", o, "%!t(cford32.ID[github.com/thehowl/cford32.idOrder]=ord_0000001)"},
+		{"%v", []ID[idUser]{1, 2}, "[0000001 0000002]"},
+	}
+	for _, tc := range tests {
+		got := fmt.Sprintf(tc.format, tc.arg)
+		testEqual(t, "Sprintf(%q, %v) = %q, want %q", tc.format, tc.arg, got, tc.want)
+	}
+}
+
+func TestParseID(t *testing.T) {
+	u, err := ParseID[idUser]("EX2YFM6")
+	if err != nil || u != 16008560262 {
+		t.Errorf("ParseID(EX2YFM6) = %d, %v, want 16008560262", u, err)
+	}
+	o, err := ParseID[idOrder]("ord_g000000000001")
+	if err != nil || o != 1 {
+		t.Errorf("ParseID(ord_g000000000001) = %d, %v, want 1", o, err)
+	}
+
+	_, err = ParseID[idOrder]("0000001")
+	testEqual(t, "ParseID(0000001) error = %v, want %v", err, error(DecodeError{Err: ErrPrefix}))
+	_, err = ParseID[idOrder]("ord_00000!1")
+	var derr DecodeError
+	if !errors.As(err, &derr) || derr.Offset != 9 || !errors.Is(err, ErrInvalidChar) {
+		t.Errorf("ParseID(ord_00000!1) error = %v, want invalid character at offset 9", err)
+	}
+}
+
+func TestIDJSON(t *testing.T) {
+	type order struct {
+		ID     ID[idOrder]        `json:"id"`
+		UserID ID[idUser]         `json:"user_id"`
+		Items  map[ID[idUser]]int `json:"items"`
+	}
+	v := order{ID: 1, UserID: 16008560262, Items: map[ID[idUser]]int{2: 3}}
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `{"id":"ord_0000001","user_id":"ex2yfm6","items":{"0000002":3}}`
+	testEqual(t, "json.Marshal = %s, want %s", string(b), want)
+
+	var got order
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != v.ID || got.UserID != v.UserID || got.Items[2] != 3 {
+		t.Errorf("json.Unmarshal(%s) = %+v, want %+v", b, got, v)
+	}
+	err = json.Unmarshal([]byte(`{"id": "0000001"}`), &got)
+	if !errors.Is(err, ErrPrefix) {
+		t.Errorf("json.Unmarshal without prefix: error = %v, want ErrPrefix", err)
+	}
+}
+
+func TestIDSQL(t *testing.T) {
+	v, err := ID[idUser](5).Value()
+	if v != int64(5) || err != nil {
+		t.Errorf("Value() = %v, %v, want 5", v, err)
+	}
+	if _, err := ID[idUser](1 << 63).Value(); err == nil {
+		t.Errorf("Value() of 1<<63 succeeded, want error")
+	}
+
+	for _, src := range []any{int64(5), []byte("5"), "5"} {
+		var id ID[idUser]
+		if err := id.Scan(src); id != 5 || err != nil {
+			t.Errorf("Scan(%#v) = %d, %v, want 5", src, uint64(id), err)
+		}
+	}
+	for _, src := range []any{int64(-1), "ex2yfm6", 1.5, nil} {
+		var id ID[idUser]
+		if err := id.Scan(src); err == nil {
+			t.Errorf("Scan(%#v) succeeded, want error", src)
+		}
+	}
+	var null sql.Null[ID[idUser]]
+	if err := null.Scan(nil); err != nil || null.Valid {
+		t.Errorf("sql.Null.Scan(nil) = %+v, %v, want invalid", null, err)
+	}
+}
@@ -0,0 +1,157 @@
+package cford32
+
+import (
+	"strconv"
+	"unicode/utf8"
+)
+
+// The lenient decoding functions accept text which was copied from documents,
+// web pages or messages, rather than typed: they work on runes, folding
+// characters which look like symbols of the encoding to their ASCII
+// equivalents, and removing invisible characters.
+
+// lenientFold maps runes which are confusable with the symbols of the encoding,
+// or with separators, to their ASCII equivalent. Runes mapped to 0 are removed.
+// Fullwidth forms are handled separately, in foldRune.
+var lenientFold = map[rune]byte{
+	// Spaces and invisible characters.
+	' ':      0,
+	'\t':     0,
+	'\u00a0': 0, // no-break space
+	'\u00ad': 0, // soft hyphen
+	'\u2007': 0, // figure space
+	'\u200b': 0, // zero width space
+	'\u200c': 0, // zero width non-joiner
+	'\u200d': 0, // zero width joiner
+	'\u202f': 0, // narrow no-break space
+	'\u2060': 0, // word joiner
+	'\u3000': 0, // ideographic space
+	'\ufeff': 0, // zero width no-break space
+
+	// Dashes.
+	'\u2010': '-', // hyphen
+	'\u2011': '-', // non-breaking hyphen
+	'\u2012': '-', // figure dash
+	'\u2013': '-', // en dash
+	'\u2014': '-', // em dash
+	'\u2015': '-', // horizontal bar
+	'\u2212': '-', // minus sign
+	'\ufe63': '-', // small hyphen-minus
+
+	// Cyrillic.
+	'\u0410': 'A', '\u0412': 'B', '\u0421': 'C', '\u0415': 'E', '\u041d': 'H', '\u0406': 'I', '\u0408': 'J',
+	'\u041a': 'K', '\u041c': 'M', '\u041e': 'O', '\u0420': 'P', '\u0405': 'S', '\u0422': 'T', '\u0425': 'X',
+	'\u04ae': 'Y', '\u0430': 'a', '\u0441': 'c', '\u0435': 'e', '\u0456': 'i', '\u0458': 'j', '\u043e': 'o',
+	'\u0440': 'p', '\u0455': 's', '\u0445': 'x', '\u0443': 'y',
+
+	// Greek.
+	'\u0391': 'A', '\u0392': 'B', '\u0395': 'E', '\u0396': 'Z', '\u0397': 'H', '\u0399': 'I', '\u039a': 'K',
+	'\u039c': 'M', '\u039d': 'N', '\u039f': 'O', '\u03a1': 'P', '\u03a4': 'T', '\u03a5': 'Y', '\u03a7': 'X',
+	'\u03bf': 'o', '\u03bd': 'v',
+}
+
+// foldRune returns the ASCII character equivalent to r, or 0 if r should be
+// removed. ok is false if r has no equivalent.
+func foldRune(r rune) (c byte, ok bool) {
+	if c, ok := lenientFold[r]; ok {
+		return c, true
+	}
+	switch {
+	case r < utf8.RuneSelf:
+		return byte(r), true
+	case r >= '\uff01' && r <= '\uff5e':
+		// Fullwidth forms of the printable ASCII characters.
+		return byte(r - 0xFEE0), true
+	}
+	return 0, false
+}
+
+// LenientError is returned by the lenient decoding functions when the input
+// contains a character which can't be decoded, even after folding it.
+// It wraps Err and a [CorruptInputError] with the same byte offset, so that it
+// can be checked using [errors.Is] and [errors.As].
+type LenientError struct {
+	Offset int64 // byte offset in the input where the error occurred
+	Index  int   // index in runes of the character in the input
+	Rune   rune  // character at Offset, if Err is about a character
+	Err    error // the reason for the error, such as ErrInvalidChar
+}
+
+func (e LenientError) Error() string {
+	s := CorruptInputError(e.Offset).Error() + " (rune " + strconv.Itoa(e.Index) + "): " + e.Err.Error()
+	if e.Err == ErrInvalidChar {
+		s += " " + strconv.QuoteRune(e.Rune)
+	}
+	return s
+}
+
+func (e LenientError) Unwrap() []error {
+	return []error{e.Err, CorruptInputError(e.Offset)}
+}
+
+// AppendNormalize appends to dst the normalized form of src, which can then be
+// decoded by the other decoding functions, and returns the extended buffer.
+//
+// Normalization folds fullwidth forms, and Cyrillic and Greek letters which
+// look like Latin ones, to their ASCII equivalents; dashes, such as en and em
+// dashes, are folded to hyphens. Spaces and invisible characters, such as
+// zero-width and no-break spaces, are removed. Other ASCII characters are
+// left as-is, including aliases and separators.
+//
+// If src contains a character which is not part of the encoding after
+// folding, or invalid UTF-8, a [LenientError] is returned, together with dst
+// extended up to the invalid character.
+func AppendNormalize(dst []byte, src string) ([]byte, error) {
+	idx := 0
+	for off, r := range src {
+		c, ok := foldRune(r)
+		if ok && c != 0 && decTable[c] == 0xFF && !isSeparator(c) {
+			ok = false
+		}
+		if !ok || r == utf8.RuneError {
+			return dst, LenientError{Offset: int64(off), Index: idx, Rune: r, Err: ErrInvalidChar}
+		}
+		if c != 0 {
+			dst = append(dst, c)
+		}
+		idx++
+	}
+	return dst, nil
+}
+
+// DecodeStringLenient is like [DecodeString], but normalizes s first, as
+// described in [AppendNormalize]; hyphens are ignored as well, like
+// newlines. Errors are reported as a [LenientError], containing both the byte
+// offset and the rune index of the invalid character.
+func DecodeStringLenient(s string) ([]byte, error) {
+	b, err := AppendNormalize(make([]byte, 0, len(s)), s)
+	if err != nil {
+		return nil, err
+	}
+	// All the characters in b are either separators or valid symbols, so
+	// decoding can't fail once the separators are removed.
+	b = AppendStripSeparators(b[:0], b)
+	n, _ := decode(b, b)
+	return b[:n], nil
+}
+
+// Uint64Lenient is like [Uint64], but normalizes s first, as described in
+// [AppendNormalize]; separators are removed as well, so that s may be
+// formatted in groups, such as "0000-001".
+//
+// Errors are reported as a [LenientError]; if the normalized input does not
+// have the length of an encoded uint64, the error has reason
+// [ErrInvalidLength] and is reported at offset 0.
+func Uint64Lenient(s string) (uint64, error) {
+	var buf [13]byte
+	b, err := AppendNormalize(buf[:0], s)
+	if err != nil {
+		return 0, err
+	}
+	v, err := Uint64(AppendStripSeparators(b[:0], b))
+	if err != nil {
+		// Only length errors are possible, as b contains valid symbols.
+		return 0, LenientError{Offset: 0, Index: 0, Err: ErrInvalidLength}
+	}
+	return v, nil
+}
@@ -0,0 +1,89 @@
+package cford32
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAppendNormalize(t *testing.T) {
+	testCases := []struct {
+		input string
+		res   string
+	}{
+		{"", ""},
+		{"CSQPYRK1E8", "CSQPYRK1E8"},
+		{"csqp-yrk1\r\ne8", "csqp-yrk1\r\ne8"},
+		{"CSQ PYR\tK1E8", "CSQPYRK1E8"},
+		{"ＣＳＱＰＹＲＫ１Ｅ８", "CSQPYRK1E8"},
+		{"ｃｓｑｐｙｒｋ１ｅ８", "csqpyrk1e8"},
+		{"CSQP–YRK1—E8−", "CSQP-YRK1-E8-"},
+		{"\ufeffCSQP\u200bYRK1\u00a0E8\u202f", "CSQPYRK1E8"},
+		// Cyrillic С, Р, К, Е and о; Greek Υ and Ο.
+		{"СSQРΥRK1Е8оΟ", "CSQPYRK1E8oO"},
+	}
+	for _, tc := range testCases {
+		res, err := AppendNormalize([]byte("x"), tc.input)
+		testEqual(t, "AppendNormalize(%q) err = %v, want %v", tc.input, err, error(nil))
+		testEqual(t, "AppendNormalize(%q) = %q, want %q", tc.input, string(res), "x"+tc.res)
+	}
+}
+
+func TestDecodeStringLenient(t *testing.T) {
+	for _, p := range pairs {
+		res, err := DecodeStringLenient(p.encoded)
+		testEqual(t, "DecodeStringLenient(%q) err = %v, want %v", p.encoded, err, error(nil))
+		testEqual(t, "DecodeStringLenient(%q) = %q, want %q", p.encoded, string(res), p.decoded)
+	}
+
+	res, err := DecodeStringLenient("ＣＳＱＰ–ＹＲＫ１\u200bＥ８")
+	testEqual(t, "DecodeStringLenient err = %v, want %v", err, error(nil))
+	testEqual(t, "DecodeStringLenient = %q, want %q", string(res), "foobar")
+
+	testCases := []struct {
+		input string
+		err   LenientError
+	}{
+		{"CSQ!", LenientError{Offset: 3, Index: 3, Rune: '!', Err: ErrInvalidChar}},
+		{"ＣＳＱ！", LenientError{Offset: 9, Index: 3, Rune: '！', Err: ErrInvalidChar}},
+		{"ＣＳＱＵ", LenientError{Offset: 9, Index: 3, Rune: 'Ｕ', Err: ErrInvalidChar}},
+		{"\u200bCSQé", LenientError{Offset: 6, Index: 4, Rune: 'é', Err: ErrInvalidChar}},
+		{"CS\xffQ", LenientError{Offset: 2, Index: 2, Rune: '\ufffd', Err: ErrInvalidChar}},
+	}
+	for _, tc := range testCases {
+		_, err := DecodeStringLenient(tc.input)
+		testEqual(t, "DecodeStringLenient(%q) err = %v, want %v", tc.input, err, error(tc.err))
+		if !errors.Is(err, ErrInvalidChar) {
+			t.Errorf("DecodeStringLenient(%q) err = %v, want it to be %v", tc.input, err, ErrInvalidChar)
+		}
+		var cerr CorruptInputError
+		if !errors.As(err, &cerr) || int64(cerr) != tc.err.Offset {
+			t.Errorf("DecodeStringLenient(%q) err = %v, want CorruptInputError(%d)", tc.input, err, tc.err.Offset)
+		}
+	}
+
+	const msg = `illegal cford32 data at input byte 9 (rune 3): invalid character '！'`
+	_, err = DecodeStringLenient("ＣＳＱ！")
+	testEqual(t, "Error() = %q, want %q", err.Error(), msg)
+}
+
+func TestUint64Lenient(t *testing.T) {
+	testCases := []struct {
+		input string
+		res   uint64
+		err   error
+	}{
+		{"0000001", 1, nil},
+		{"0000-001", 1, nil},
+		{"ОoОoОoL", 1, nil},
+		{"ｇ００００００００００ｉ０", 32, nil},
+		{"g000-00fz-zzzzz", 1<<34 - 1, nil},
+		{"000001", 0, LenientError{Err: ErrInvalidLength}},
+		{"g000000", 0, LenientError{Err: ErrInvalidLength}},
+		{"000é001", 0, LenientError{Offset: 3, Index: 3, Rune: 'é', Err: ErrInvalidChar}},
+	}
+	for _, tc := range testCases {
+		res, err := Uint64Lenient(tc.input)
+		testEqual(t, "Uint64Lenient(%q) err = %v, want %v", tc.input, err, tc.err)
+		testEqual(t, "Uint64Lenient(%q) = %v, want %v", tc.input, res, tc.res)
+	}
+}
@@ -0,0 +1,80 @@
+package cford32
+
+import "slices"
+
+// Ordered encoding
+//
+// The data encoded by Encode sorts like the raw data, but the encoding of a
+// prefix of the data is generally not a prefix of the encoding of the data,
+// as the last symbol of the encoding mixes the last bits of the data with
+// padding. The ordered encoding instead encodes each byte b with two symbols,
+// 1+b>>5 and b&31, and ends with the symbol 0, which sorts before the first
+// symbol of all bytes. As a result:
+//
+//   - the encodings sort like the data, whatever their length;
+//   - no encoding is a prefix of another, so encodings can be concatenated
+//     to build composite keys, which sort like their elements;
+//   - the encoding of a prefix of the data, without its final 0, is a prefix
+//     of the encoding of the data.
+
+// OrderedLen returns the length of the ordered encoding of n bytes.
+func OrderedLen(n int) int { return 2*n + 1 }
+
+// AppendOrdered appends the ordered encoding of src to dst, and returns the
+// extended buffer.
+//
+// The ordered encodings of byte strings sort exactly like the byte strings,
+// as compared by [bytes.Compare], as long as they all use the same case. They
+// are [OrderedLen](len(src)) bytes long, 25% more than the encoding of
+// [Encode], and end with the symbol '0'; removing it leaves a prefix of the
+// ordered encodings of all the byte strings starting with src, which can be
+// used for prefix scans in sorted stores.
+//
+// As no ordered encoding is a prefix of another, they can be concatenated to
+// build composite keys, and decoded one by one using [DecodeOrdered].
+func AppendOrdered(dst, src []byte) []byte {
+	return appendOrdered(dst, src, encTable)
+}
+
+// AppendOrderedLower is like [AppendOrdered], but uses the lowercase variation
+// of the encoding.
+func AppendOrderedLower(dst, src []byte) []byte {
+	return appendOrdered(dst, src, encTableLower)
+}
+
+func appendOrdered(dst, src []byte, table string) []byte {
+	dst = slices.Grow(dst, OrderedLen(len(src)))
+	for _, b := range src {
+		dst = append(dst, table[1+b>>5], table[b&31])
+	}
+	return append(dst, '0')
+}
+
+// DecodeOrdered decodes the ordered encoding at the start of src, as produced
+// by [AppendOrdered], and appends the decoded data to dst. It returns the
+// extended buffer, and the length of the encoding read from src, including
+// its terminator; the rest of src is not read.
+//
+// Both cases are accepted, with the aliases accepted by [Decode]. If src does
+// not start with an ordered encoding, a [DecodeError] is returned, with
+// reason [ErrInvalidChar], or [ErrInvalidLength] if src ends before the
+// terminator.
+func DecodeOrdered(dst, src []byte) (data []byte, n int, err error) {
+	for i := 0; i < len(src); i += 2 {
+		hi := decTable[src[i]]
+		switch {
+		case hi == 0:
+			return dst, i + 1, nil
+		case hi > 8:
+			return dst, 0, DecodeError{Offset: int64(i), Char: src[i], Err: ErrInvalidChar}
+		case i+1 == len(src):
+			return dst, 0, DecodeError{Offset: int64(len(src)), Err: ErrInvalidLength}
+		}
+		lo := decTable[src[i+1]]
+		if lo == 0xff {
+			return dst, 0, DecodeError{Offset: int64(i + 1), Char: src[i+1], Err: ErrInvalidChar}
+		}
+		dst = append(dst, (hi-1)<<5|lo)
+	}
+	return dst, 0, DecodeError{Offset: int64(len(src)), Err: ErrInvalidLength}
+}
@@ -0,0 +1,108 @@
+package cford32
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestOrdered(t *testing.T) {
+	tests := []struct {
+		data string
+		enc  string
+	}{
+		{"", "0"},
+		{"\x00", "100"},
+		{"\x00\x00", "10100"},
+		{"\x1f", "1Z0"},
+		{"\x20", "200"},
+		{"\xff", "8Z0"},
+		{"a", "410"},
+		{"ab", "41420"},
+	}
+	for _, tc := range tests {
+		enc := AppendOrdered(nil, []byte(tc.data))
+		testEqual(t, "AppendOrdered(%q) = %s, want %s", tc.data, string(enc), tc.enc)
+		testEqual(t, "OrderedLen(%d) = %d, want %d", len(tc.data), OrderedLen(len(tc.data)), len(enc))
+		lower := AppendOrderedLower(nil, []byte(tc.data))
+		testEqual(t, "AppendOrderedLower(%q) = %s, want %s", tc.data, string(lower), string(bytes.ToLower(enc)))
+
+		dec, n, err := DecodeOrdered(nil, append(lower, "xyz"...))
+		if err != nil || string(dec) != tc.data || n != len(enc) {
+			t.Errorf("DecodeOrdered(%s) = %q, %d, %v, want %q, %d, nil", lower, dec, n, err, tc.data, len(enc))
+		}
+	}
+}
+
+func TestOrderedSort(t *testing.T) {
+	gen := rand.New(rand.NewSource(42))
+	data := make([][]byte, 500)
+	for i := range data {
+		b := make([]byte, gen.Intn(6))
+		for j := range b {
+			// Mostly use a few values, so that many strings share prefixes.
+			if gen.Intn(4) == 0 {
+				b[j] = byte(gen.Intn(256))
+			} else {
+				b[j] = byte(gen.Intn(3)) * 0x7f
+			}
+		}
+		data[i] = b
+	}
+	sort.Slice(data, func(i, j int) bool { return bytes.Compare(data[i], data[j]) < 0 })
+	for i := 1; i < len(data); i++ {
+		a, b := AppendOrdered(nil, data[i-1]), AppendOrdered(nil, data[i])
+		if c, want := bytes.Compare(a, b), bytes.Compare(data[i-1], data[i]); c != want {
+			t.Errorf("compare(%x, %x) = %d, want %d", a, b, c, want)
+		}
+		// The encoding of a prefix of the data, without its terminator, is a
+		// prefix of the encoding of the data.
+		for j := 0; j <= len(data[i]); j++ {
+			p := AppendOrdered(nil, data[i][:j])
+			if !bytes.HasPrefix(b, p[:len(p)-1]) {
+				t.Errorf("%s is not a prefix of %s", p[:len(p)-1], b)
+			}
+		}
+	}
+}
+
+func TestOrderedChained(t *testing.T) {
+	var key []byte
+	parts := []string{"users", "", "\x00\xff"}
+	for _, p := range parts {
+		key = AppendOrdered(key, []byte(p))
+	}
+	for _, p := range parts {
+		dec, n, err := DecodeOrdered(nil, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testEqual(t, "DecodeOrdered = %q, want %q", string(dec), p)
+		key = key[n:]
+	}
+	testEqual(t, "remaining %q, want %q", string(key), "")
+}
+
+func TestDecodeOrderedErrors(t *testing.T) {
+	tests := []struct {
+		src    string
+		offset int64
+		err    error
+	}{
+		{"", 0, ErrInvalidLength},
+		{"41", 2, ErrInvalidLength},
+		{"414", 3, ErrInvalidLength},
+		{"9A0", 0, ErrInvalidChar},
+		{"41!10", 2, ErrInvalidChar},
+		{"4U0", 1, ErrInvalidChar},
+	}
+	for _, tc := range tests {
+		_, n, err := DecodeOrdered(nil, []byte(tc.src))
+		var derr DecodeError
+		if !errors.As(err, &derr) || derr.Offset != tc.offset || !errors.Is(err, tc.err) || n != 0 {
+			t.Errorf("DecodeOrdered(%q) = %d, %v, want error at %d: %v", tc.src, n, err, tc.offset, tc.err)
+		}
+	}
+}
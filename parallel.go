@@ -0,0 +1,160 @@
+package cford32
+
+import (
+	"runtime"
+	"sync"
+)
+
+// defaultParallelThreshold is the default value of
+// [ParallelOptions.Threshold].
+const defaultParallelThreshold = 1 << 20
+
+// ParallelOptions configures the parallel encoding and decoding functions.
+// The zero value is ready to use.
+type ParallelOptions struct {
+	// Workers is the maximum number of goroutines used to process the input.
+	// If zero, [runtime.GOMAXPROCS] is used.
+	Workers int
+	// Threshold is the minimum length of the input for it to be split between
+	// multiple goroutines; shorter inputs are processed on the calling
+	// goroutine. If zero, 1 MiB is used.
+	Threshold int
+}
+
+// workers returns the number of goroutines to use for an input of length n.
+func (o ParallelOptions) workers(n int) int {
+	threshold := o.Threshold
+	if threshold <= 0 {
+		threshold = defaultParallelThreshold
+	}
+	if n < threshold {
+		return 1
+	}
+	workers := o.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	return max(min(workers, n), 1)
+}
+
+// runParallel calls f(0) through f(n-1), each on its own goroutine, and waits
+// for them to return.
+func runParallel(n int, f func(i int)) {
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			f(i)
+		}()
+	}
+	wg.Wait()
+}
+
+// EncodeParallel is like [Encode], but it splits large inputs between
+// multiple goroutines, as configured by opts. The output is the same as
+// Encode's.
+func EncodeParallel(dst, src []byte, opts ParallelOptions) {
+	encodeParallel(dst, src, opts, Encode)
+}
+
+// EncodeLowerParallel is like [EncodeParallel], but uses the lowercase
+// variation of the encoding.
+func EncodeLowerParallel(dst, src []byte, opts ParallelOptions) {
+	encodeParallel(dst, src, opts, EncodeLower)
+}
+
+func encodeParallel(dst, src []byte, opts ParallelOptions, enc func(dst, src []byte)) {
+	// Like Encode, panic if dst is too short; but do so before starting
+	// any goroutine, so that the caller may recover.
+	dst = dst[:EncodedLen(len(src))]
+
+	workers := opts.workers(len(src))
+	if workers == 1 {
+		enc(dst, src)
+		return
+	}
+
+	// Split src in chunks made of whole 5-byte blocks.
+	size := (len(src)/workers + 4) / 5 * 5
+	runParallel((len(src)+size-1)/size, func(i int) {
+		start, end := i*size, min((i+1)*size, len(src))
+		enc(dst[EncodedLen(start):EncodedLen(end)], src[start:end])
+	})
+}
+
+// DecodeParallel is like [Decode], but it splits large inputs between
+// multiple goroutines, as configured by opts.
+//
+// The decoded output, the returned number of bytes and error are the same as
+// Decode's, including the offset of any [CorruptInputError]. However, when
+// an error occurs, the bytes of dst after the returned number of bytes may
+// have been written to, as the rest of the input is decoded concurrently.
+func DecodeParallel(dst, src []byte, opts ParallelOptions) (n int, err error) {
+	workers := opts.workers(len(src))
+	if workers == 1 {
+		return Decode(dst, src)
+	}
+
+	// Quanta are made of 8 characters, not counting newlines. To split src
+	// on quantum boundaries, count the characters in evenly sized parts of
+	// src, then move the start of each part forward to the next boundary.
+	size := (len(src) + workers - 1) / workers
+	counts := make([]int, workers)
+	runParallel(workers, func(i int) {
+		for _, c := range src[min(i*size, len(src)):min((i+1)*size, len(src))] {
+			if !isNewline(c) {
+				counts[i]++
+			}
+		}
+	})
+
+	type chunk struct {
+		start int // start of the chunk in src
+		chars int // characters before the chunk, not counting newlines
+		off   int // start of the chunk's output in dst
+		n     int
+		err   error
+	}
+	chunks := make([]chunk, 1, workers)
+	chars := counts[0]
+	for i := 1; i < workers; i++ {
+		pos, ch := i*size, chars
+		chars += counts[i]
+		if pos < chunks[len(chunks)-1].start {
+			// The previous chunk already starts after this part
+			// (after a long run of newlines).
+			continue
+		}
+		for ; ch%8 != 0 && pos < len(src); pos++ {
+			if !isNewline(src[pos]) {
+				ch++
+			}
+		}
+		if pos >= len(src) {
+			break
+		}
+		chunks = append(chunks, chunk{start: pos, chars: ch, off: ch / 8 * 5})
+	}
+
+	runParallel(len(chunks), func(i int) {
+		c := &chunks[i]
+		end, dend := len(src), len(dst)
+		if i+1 < len(chunks) {
+			end, dend = chunks[i+1].start, min(chunks[i+1].off, len(dst))
+		}
+		c.n, c.err = Decode(dst[min(c.off, len(dst)):dend], src[c.start:end])
+	})
+
+	// Return the first error, as Decode would.
+	for _, c := range chunks {
+		if c.err != nil {
+			if off, ok := c.err.(CorruptInputError); ok {
+				c.err = off + CorruptInputError(c.chars)
+			}
+			return c.off + c.n, c.err
+		}
+	}
+	last := chunks[len(chunks)-1]
+	return last.off + last.n, nil
+}
@@ -0,0 +1,89 @@
+package cford32
+
+import (
+	"bytes"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestEncodeParallel(t *testing.T) {
+	rnd := rand.New(rand.NewPCG(1, 2))
+	for _, l := range []int{0, 1, 5, 9, 10, 64, 1000, 1<<16 + 3} {
+		src := make([]byte, l)
+		for i := range src {
+			src[i] = byte(rnd.Uint32())
+		}
+		for _, workers := range []int{1, 2, 3, 7, 16} {
+			opts := ParallelOptions{Workers: workers, Threshold: 1}
+			got := make([]byte, EncodedLen(l))
+			EncodeParallel(got, src, opts)
+			testEqual(t, "EncodeParallel(%d bytes, %d workers) = %q, want %q", l, workers, string(got), EncodeToString(src))
+			EncodeLowerParallel(got, src, opts)
+			testEqual(t, "EncodeLowerParallel(%d bytes, %d workers) = %q, want %q", l, workers, string(got), EncodeToStringLower(src))
+		}
+	}
+}
+
+func TestDecodeParallel(t *testing.T) {
+	rnd := rand.New(rand.NewPCG(1, 2))
+	for iter := 0; iter < 500; iter++ {
+		raw := make([]byte, rnd.IntN(2000))
+		for i := range raw {
+			raw[i] = byte(rnd.Uint32())
+		}
+		src := []byte(EncodeToString(raw))
+
+		// Add newlines, sometimes in long runs, and occasionally
+		// invalid characters.
+		for i := rnd.IntN(len(src) + 1); i > 0; i-- {
+			pos := rnd.IntN(len(src) + 1)
+			sep := bytes.Repeat([]byte{"\n\r"[rnd.IntN(2)]}, 1+rnd.IntN(1+rnd.IntN(100)))
+			src = append(src[:pos], append(sep, src[pos:]...)...)
+		}
+		for i := rnd.IntN(3) - 1; i > 0; i-- {
+			src[rnd.IntN(len(src))] = "!-"[rnd.IntN(2)]
+		}
+
+		dstLen := DecodedLen(len(src))
+		if rnd.IntN(4) == 0 {
+			dstLen = rnd.IntN(dstLen + 1)
+		}
+		want := make([]byte, dstLen)
+		wantN, wantErr := Decode(want, src)
+
+		for _, workers := range []int{2, 3, 8, 64} {
+			got := make([]byte, dstLen)
+			gotN, gotErr := DecodeParallel(got, src, ParallelOptions{Workers: workers, Threshold: 1})
+			if gotN != wantN || gotErr != wantErr || !bytes.Equal(got[:gotN], want[:wantN]) {
+				t.Fatalf("DecodeParallel(%q, %d workers) into %d bytes = %d, %v; want %d, %v",
+					src, workers, dstLen, gotN, gotErr, wantN, wantErr)
+			}
+		}
+	}
+}
+
+func TestParallelThreshold(t *testing.T) {
+	testEqual(t, "workers below threshold = %d, want %d", ParallelOptions{Workers: 8}.workers(1<<20-1), 1)
+	testEqual(t, "workers above threshold = %d, want %d", ParallelOptions{Workers: 8}.workers(1<<20), 8)
+	testEqual(t, "workers with custom threshold = %d, want %d", ParallelOptions{Workers: 8, Threshold: 100}.workers(100), 8)
+	testEqual(t, "workers on tiny input = %d, want %d", ParallelOptions{Workers: 8, Threshold: 1}.workers(3), 3)
+}
+
+func BenchmarkEncodeParallel(b *testing.B) {
+	data := make([]byte, 64<<20)
+	buf := make([]byte, EncodedLen(len(data)))
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		EncodeParallel(buf, data, ParallelOptions{})
+	}
+}
+
+func BenchmarkDecodeParallel(b *testing.B) {
+	data := make([]byte, EncodedLen(64<<20))
+	Encode(data, make([]byte, 64<<20))
+	buf := make([]byte, 64<<20)
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		DecodeParallel(buf, data, ParallelOptions{})
+	}
+}
@@ -0,0 +1,89 @@
+package cford32
+
+import "encoding/binary"
+
+// This file contains the SWAR (SIMD within a register) implementations of
+// encoding and decoding, which process whole quanta at a time using 64-bit
+// loads, stores and arithmetic. They are used by [Encode] and [Decode] for
+// the bulk of the input, leaving the edge cases to the scalar
+// implementations.
+
+// encPairs and encPairsLower map each 10-bit value to the two characters
+// encoding it, so that a quantum is encoded with four lookups.
+var encPairs, encPairsLower = makePairs(encTable), makePairs(encTableLower)
+
+func makePairs(table string) (t [1024]uint16) {
+	for i := range t {
+		t[i] = uint16(table[i>>5])<<8 | uint16(table[i&31])
+	}
+	return t
+}
+
+// encodeSWAR encodes the longest prefix of src made of pairs of 5-byte
+// blocks which can be processed with 8-byte loads, and returns its length.
+func encodeSWAR(dst, src []byte, lower bool) (n int) {
+	pairs := &encPairs
+	if lower {
+		pairs = &encPairsLower
+	}
+
+	di := 0
+	for len(src)-n >= 13 && len(dst)-di >= 16 {
+		s := src[n : n+13]
+		d := dst[di : di+16]
+
+		// Load 5 bytes at a time, and store the 8 characters at once.
+		v := binary.BigEndian.Uint64(s) >> 24
+		binary.BigEndian.PutUint64(d, uint64(pairs[v>>30&0x3FF])<<48|
+			uint64(pairs[v>>20&0x3FF])<<32|
+			uint64(pairs[v>>10&0x3FF])<<16|
+			uint64(pairs[v&0x3FF]))
+		v = binary.BigEndian.Uint64(s[5:]) >> 24
+		binary.BigEndian.PutUint64(d[8:], uint64(pairs[v>>30&0x3FF])<<48|
+			uint64(pairs[v>>20&0x3FF])<<32|
+			uint64(pairs[v>>10&0x3FF])<<16|
+			uint64(pairs[v&0x3FF]))
+
+		n += 10
+		di += 16
+	}
+	return n
+}
+
+// decodeSWAR decodes the longest prefix of src made of whole 8-character
+// quanta containing only valid characters. It returns the number of bytes
+// written to dst, and the number of bytes of src decoded.
+func decodeSWAR(dst, src []byte) (n, si int) {
+	for len(src)-si >= 8 && len(dst)-n >= 5 {
+		s := src[si : si+8]
+		v := uint64(decTable[s[0]])<<56 |
+			uint64(decTable[s[1]])<<48 |
+			uint64(decTable[s[2]])<<40 |
+			uint64(decTable[s[3]])<<32 |
+			uint64(decTable[s[4]])<<24 |
+			uint64(decTable[s[5]])<<16 |
+			uint64(decTable[s[6]])<<8 |
+			uint64(decTable[s[7]])
+
+		// Invalid characters and newlines are 0xFF in decTable;
+		// leave them to the scalar implementation.
+		if v&0xE0E0E0E0E0E0E0E0 != 0 {
+			break
+		}
+
+		// Pack the eight 5-bit values into 40 bits.
+		v = v&0x1F001F001F001F00>>3 | v&0x001F001F001F001F
+		v = v&0x03FF000003FF0000>>6 | v&0x000003FF000003FF
+		v = v&0x000FFFFF00000000>>12 | v&0x00000000000FFFFF
+
+		d := dst[n : n+5]
+		d[0] = byte(v >> 32)
+		d[1] = byte(v >> 24)
+		d[2] = byte(v >> 16)
+		d[3] = byte(v >> 8)
+		d[4] = byte(v)
+		n += 5
+		si += 8
+	}
+	return n, si
+}
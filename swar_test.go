@@ -0,0 +1,96 @@
+package cford32
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEncodeSWARTable(t *testing.T) {
+	// Encode every 5-bit value in every lane.
+	src := make([]byte, 5*32+3)
+	for i := 0; i < 32*8; i++ {
+		// Set the i-th group of 5 bits to i%32.
+		v := byte(i % 32)
+		for b := 0; b < 5; b++ {
+			if v&(0x10>>b) != 0 {
+				bit := i*5 + b
+				src[bit/8] |= 0x80 >> (bit % 8)
+			}
+		}
+	}
+	for _, tc := range []struct {
+		lower bool
+		table string
+	}{{false, encTable}, {true, encTableLower}} {
+		want := make([]byte, EncodedLen(len(src)))
+		encodeScalar(want, src, tc.table)
+		got := make([]byte, EncodedLen(len(src)))
+		n := encodeSWAR(got, src, tc.lower)
+		// The trailing 3 bytes are left to the scalar implementation.
+		if n != 5*32 {
+			t.Errorf("encodeSWAR encoded %d bytes, want %d", n, 5*32)
+		}
+		testEqual(t, "encodeSWAR(lower: %v) = %q, want %q", tc.lower, string(got[:EncodedLen(n)]), string(want[:EncodedLen(n)]))
+	}
+}
+
+func FuzzEncodeSWAR(f *testing.F) {
+	for _, p := range append(pairs, bigtest) {
+		f.Add([]byte(p.decoded))
+	}
+	f.Fuzz(func(t *testing.T, src []byte) {
+		for _, tc := range []struct {
+			encode func(dst, src []byte)
+			table  string
+		}{{Encode, encTable}, {EncodeLower, encTableLower}} {
+			want := make([]byte, EncodedLen(len(src)))
+			encodeScalar(want, src, tc.table)
+			got := make([]byte, EncodedLen(len(src)))
+			tc.encode(got, src)
+			if !bytes.Equal(got, want) {
+				t.Errorf("Encode(%q) = %q, scalar = %q", src, got, want)
+			}
+		}
+	})
+}
+
+func FuzzDecodeSWAR(f *testing.F) {
+	for _, p := range append(pairs, bigtest) {
+		f.Add(p.encoded, len(p.decoded))
+		f.Add(strings.ToLower(p.encoded)+"\n"+p.encoded, len(p.decoded))
+	}
+	f.Add("D1JP-RV3F\nEXQQ4V34iIoOlLUU", 20)
+	f.Add("D1JPRV3FEXQQ4V34D1JPRV3FEX!Q4V34", 20)
+	f.Fuzz(func(t *testing.T, src string, dstLen int) {
+		if dstLen < 0 || dstLen > DecodedLen(len(src)) {
+			dstLen = DecodedLen(len(src))
+		}
+		want := make([]byte, dstLen)
+		wantN, _, wantErr := decodeWith(want, []byte(src), false)
+		got := make([]byte, dstLen)
+		gotN, _, gotErr := decodeWith(got, []byte(src), true)
+		if gotN != wantN || gotErr != wantErr || !bytes.Equal(got, want) {
+			t.Errorf("decode(%q) = %d, %v, %q; scalar = %d, %v, %q", src, gotN, gotErr, got, wantN, wantErr, want)
+		}
+	})
+}
+
+func BenchmarkEncodeScalar(b *testing.B) {
+	data := make([]byte, 8192)
+	buf := make([]byte, EncodedLen(len(data)))
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		encodeScalar(buf, data, encTable)
+	}
+}
+
+func BenchmarkDecodeScalar(b *testing.B) {
+	data := make([]byte, EncodedLen(8192))
+	Encode(data, make([]byte, 8192))
+	buf := make([]byte, 8192)
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		decodeWith(buf, data, false)
+	}
+}
@@ -0,0 +1,546 @@
+package cford32
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// This file implements the encoding of struct fields tagged with "cford32",
+// by converting the values to "shadow" values of types built with
+// [reflect.StructOf], where the tagged fields are replaced by a type
+// implementing [encoding.TextMarshaler] and [encoding.TextUnmarshaler].
+
+// MarshalJSON returns the JSON encoding of v, like [json.Marshal], encoding
+// the integer fields of structs tagged with "cford32" as strings.
+//
+// The tag selects the encoding of the field, with the value "compact" or
+// "full", optionally followed by ",upper" or ",lower"; the default is the
+// lowercase variation, as used by [PutCompact]:
+//
+//	type User struct {
+//		ID      uint64 `json:"id" cford32:"compact"`
+//		OrgID   uint32 `json:"org_id" cford32:"full,upper"`
+//		Friends []int64 `json:"friends" cford32:"compact"`
+//	}
+//
+// Tagged fields may have any type with an underlying type of uint64, uint32
+// or int64, or pointers, slices and arrays of them. Negative int64 values
+// can't be encoded, and return a [FieldError].
+//
+// The other fields are encoded as usual, and may contain tagged structs;
+// values stored in interfaces are not converted. Unexported fields are not
+// encoded, and structs containing tagged fields can't embed unexported
+// structs, or types with methods.
+func MarshalJSON(v any) ([]byte, error) {
+	sv, err := Tagged(v)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(sv)
+}
+
+// UnmarshalJSON parses the JSON-encoded data and stores the result in the
+// value pointed to by v, like [json.Unmarshal], decoding the fields tagged
+// with "cford32" as described in [MarshalJSON].
+//
+// Tagged fields accept both the compact and full encodings, in any case. An
+// invalid value returns a [FieldError] naming the field.
+func UnmarshalJSON(data []byte, v any) error {
+	return UnmarshalTagged(v, func(sv any) error {
+		return json.Unmarshal(data, sv)
+	})
+}
+
+// Tagged returns a copy of v, where the fields tagged with "cford32", as
+// described in [MarshalJSON], are replaced by values implementing
+// [encoding.TextMarshaler]. It allows encoding v with encoders other than
+// encoding/json which support TextMarshaler, such as encoding/xml:
+//
+//	sv, err := cford32.Tagged(user)
+//	if err != nil {
+//		return err
+//	}
+//	return xml.NewEncoder(w).Encode(sv)
+//
+// If v does not contain tagged fields, it is returned as-is.
+func Tagged(v any) (any, error) {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		return v, nil
+	}
+	p, err := planFor(rv.Type())
+	if err != nil || p == nil {
+		return v, err
+	}
+	sv := reflect.New(p.typ).Elem()
+	if err := p.toShadow(sv, rv, rootName(rv.Type()), true); err != nil {
+		return nil, err
+	}
+	return sv.Interface(), nil
+}
+
+// UnmarshalTagged calls unmarshal with a pointer to a value where the fields
+// tagged with "cford32" are replaced by values implementing
+// [encoding.TextUnmarshaler], and stores the result in the value pointed to
+// by v, decoding the tagged fields as described in [UnmarshalJSON]. It allows
+// decoding v with decoders other than encoding/json:
+//
+//	err := cford32.UnmarshalTagged(&user, func(sv any) error {
+//		return xml.Unmarshal(data, sv)
+//	})
+//
+// The value passed to unmarshal is initialized with the value pointed to by
+// v, so that fields missing from the input are left unchanged.
+func UnmarshalTagged(v any, unmarshal func(any) error) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("cford32: UnmarshalTagged(non-pointer or nil %T)", v)
+	}
+	rv = rv.Elem()
+	p, err := planFor(rv.Type())
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		return unmarshal(v)
+	}
+	sv := reflect.New(p.typ)
+	p.toShadow(sv.Elem(), rv, "", false)
+	if err := unmarshal(sv.Interface()); err != nil {
+		return err
+	}
+	return p.fromShadow(rv, sv.Elem(), rootName(rv.Type()))
+}
+
+// FieldError is returned when the value of a field tagged with "cford32"
+// can't be encoded or decoded, or when the field can't be tagged.
+type FieldError struct {
+	Field string // the path of the field, such as "User.Friends[2]"
+	Err   error  // the error, such as a DecodeError
+}
+
+func (e *FieldError) Error() string {
+	return "cford32: field " + e.Field + ": " + e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error { return e.Err }
+
+// rootName returns the name used for the root value of type t in the paths
+// of FieldErrors.
+func rootName(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Name() != "" {
+		return t.Name()
+	}
+	return t.String()
+}
+
+// idText is the type replacing tagged fields in shadow values, as a pointer:
+// it is nil for zero values omitted by the encoders, and when decoding,
+// unless the field was set by the decoder.
+type idText struct {
+	text []byte
+	// notString is the kind of the JSON value decoded in place of a string,
+	// such as "number", so that fromShadow can report it with the type of
+	// the field rather than idText.
+	notString string
+}
+
+var idTextType = reflect.TypeOf(&idText{})
+
+func (t idText) MarshalText() ([]byte, error) { return t.text, nil }
+
+func (t *idText) UnmarshalText(b []byte) error {
+	t.text = append([]byte{}, b...)
+	return nil
+}
+
+func (t *idText) UnmarshalJSON(b []byte) error {
+	switch b[0] {
+	case '"':
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		t.text = []byte(s)
+		return nil
+	case 't', 'f':
+		t.notString = "bool"
+	case '[':
+		t.notString = "array"
+	case '{':
+		t.notString = "object"
+	default:
+		t.notString = "number"
+	}
+	return nil
+}
+
+// idFormat is the encoding of a tagged field.
+type idFormat struct {
+	full  bool
+	upper bool
+}
+
+func parseTag(tag string) (f idFormat, err error) {
+	form, rest, _ := strings.Cut(tag, ",")
+	switch form {
+	case "compact":
+	case "full":
+		f.full = true
+	default:
+		return f, fmt.Errorf("invalid cford32 tag %q: must start with compact or full", tag)
+	}
+	switch rest {
+	case "", "lower":
+	case "upper":
+		f.upper = true
+	default:
+		return f, fmt.Errorf("invalid cford32 tag %q: invalid option %q", tag, rest)
+	}
+	return f, nil
+}
+
+func (f idFormat) encode(v uint64) []byte {
+	switch {
+	case f.full && f.upper:
+		b := PutUint64(v)
+		return b[:]
+	case f.full:
+		b := PutUint64Lower(v)
+		return b[:]
+	case f.upper:
+		return []byte(strings.ToUpper(string(PutCompact(v))))
+	}
+	return PutCompact(v)
+}
+
+// plan describes how to convert values of a type to their shadow values.
+// A nil plan means that the type is unchanged.
+type plan struct {
+	typ    reflect.Type // the type of the shadow values
+	id     *idFormat    // for tagged integers
+	omit   bool         // for tagged integers, whether to omit zero values
+	elem   *plan        // for pointers, slices, arrays and maps
+	fields []fieldPlan  // for structs
+}
+
+type fieldPlan struct {
+	index int    // index of the field in the original struct
+	name  string // name of the field
+	*plan
+}
+
+var plans sync.Map // reflect.Type -> *plan, or error
+
+// planFor returns the plan for values of type t, which is nil if t doesn't
+// contain tagged fields.
+func planFor(t reflect.Type) (*plan, error) {
+	if p, ok := plans.Load(t); ok {
+		if err, ok := p.(error); ok {
+			return nil, err
+		}
+		return p.(*plan), nil
+	}
+	p, err := newPlan(t, map[reflect.Type]bool{})
+	if err != nil {
+		plans.Store(t, err)
+		return nil, err
+	}
+	plans.Store(t, p)
+	return p, nil
+}
+
+func newPlan(t reflect.Type, visiting map[reflect.Type]bool) (p *plan, err error) {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map, reflect.Struct:
+	default:
+		return nil, nil
+	}
+	if visiting[t] {
+		// Recursive types are only supported if they don't contain tagged
+		// fields, as StructOf can't build recursive types.
+		if hasTags(t, map[reflect.Type]bool{}) {
+			return nil, fmt.Errorf("cford32: recursive type %v with tagged fields is not supported", t)
+		}
+		return nil, nil
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	if t.Kind() != reflect.Struct {
+		elem, err := newPlan(t.Elem(), visiting)
+		if elem == nil || err != nil {
+			return nil, err
+		}
+		return &plan{typ: wrapType(t, elem.typ), elem: elem}, nil
+	}
+
+	var fields []reflect.StructField
+	var fplans []fieldPlan
+	changed := false
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		var fp *plan
+		if tag, ok := f.Tag.Lookup("cford32"); ok && tag != "-" {
+			format, err := parseTag(tag)
+			if err == nil {
+				fp, err = idPlan(f.Type, format, omitsEmpty(f.Tag))
+			}
+			if err != nil {
+				return nil, &FieldError{Field: rootName(t) + "." + f.Name, Err: err}
+			}
+		} else if fp, err = newPlan(f.Type, visiting); err != nil {
+			return nil, err
+		}
+		changed = changed || fp != nil
+		if !f.IsExported() && !f.Anonymous {
+			// Unexported fields are ignored by the encoders, but they can't
+			// be used with StructOf.
+			continue
+		}
+		fields = append(fields, f)
+		fplans = append(fplans, fieldPlan{index: i, name: f.Name, plan: fp})
+		if fp != nil {
+			fields[len(fields)-1].Type = fp.typ
+		}
+	}
+	if !changed {
+		return nil, nil
+	}
+	for i := range fields {
+		if fields[i].Anonymous && !fields[i].IsExported() {
+			return nil, fmt.Errorf("cford32: struct %v with tagged fields can't embed unexported field %s", t, fields[i].Name)
+		}
+		fields[i].Index, fields[i].Offset = nil, 0
+	}
+	st, err := structOf(fields)
+	if err != nil {
+		return nil, fmt.Errorf("cford32: struct %v with tagged fields is not supported: %v", t, err)
+	}
+	return &plan{typ: st, fields: fplans}, nil
+}
+
+// structOf is like reflect.StructOf, but returns its panics as errors, as
+// when embedding types with methods.
+func structOf(fields []reflect.StructField) (t reflect.Type, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	return reflect.StructOf(fields), nil
+}
+
+// idPlan returns the plan for a field of type t tagged with the given format.
+// If omit is set, zero values are omitted.
+func idPlan(t reflect.Type, format idFormat, omit bool) (*plan, error) {
+	switch t.Kind() {
+	case reflect.Uint64, reflect.Uint32, reflect.Int64:
+		return &plan{typ: idTextType, id: &format, omit: omit}, nil
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		elem, err := idPlan(t.Elem(), format, false)
+		if err != nil {
+			return nil, err
+		}
+		return &plan{typ: wrapType(t, elem.typ), elem: elem}, nil
+	}
+	return nil, fmt.Errorf("type %v can't be tagged with cford32", t)
+}
+
+// omitsEmpty reports whether the encoders omit the field with the given tag
+// when it is empty, using the omitempty or omitzero options.
+func omitsEmpty(tag reflect.StructTag) bool {
+	for _, key := range []string{"json", "xml", "yaml", "toml"} {
+		_, opts, _ := strings.Cut(tag.Get(key), ",")
+		for _, opt := range strings.Split(opts, ",") {
+			if opt == "omitempty" || opt == "omitzero" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// wrapType returns a type of the same kind as t, with elem as its element
+// type.
+func wrapType(t, elem reflect.Type) reflect.Type {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return reflect.PointerTo(elem)
+	case reflect.Slice:
+		return reflect.SliceOf(elem)
+	case reflect.Array:
+		return reflect.ArrayOf(t.Len(), elem)
+	case reflect.Map:
+		return reflect.MapOf(t.Key(), elem)
+	}
+	panic("cford32: invalid kind " + t.Kind().String())
+}
+
+// hasTags reports whether values of type t may contain tagged fields.
+func hasTags(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return hasTags(t.Elem(), seen)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if tag, ok := f.Tag.Lookup("cford32"); ok && tag != "-" || hasTags(f.Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// toShadow copies v to its shadow value sv, at the given path. If encode is
+// false, tagged fields are left unset, for decoding.
+func (p *plan) toShadow(sv, v reflect.Value, path string, encode bool) error {
+	switch {
+	case p == nil:
+		sv.Set(v)
+	case p.id != nil:
+		if !encode || p.omit && v.IsZero() {
+			return nil
+		}
+		var n uint64
+		switch v.Kind() {
+		case reflect.Int64:
+			if v.Int() < 0 {
+				return &FieldError{Field: path, Err: fmt.Errorf("negative value %d", v.Int())}
+			}
+			n = uint64(v.Int())
+		default:
+			n = v.Uint()
+		}
+		sv.Set(reflect.ValueOf(&idText{text: p.id.encode(n)}))
+	case v.Kind() == reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		sv.Set(reflect.New(p.typ.Elem()))
+		return p.elem.toShadow(sv.Elem(), v.Elem(), path, encode)
+	case v.Kind() == reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		sv.Set(reflect.MakeSlice(p.typ, v.Len(), v.Len()))
+		fallthrough
+	case v.Kind() == reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := p.elem.toShadow(sv.Index(i), v.Index(i), fmt.Sprintf("%s[%d]", path, i), encode); err != nil {
+				return err
+			}
+		}
+	case v.Kind() == reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		sv.Set(reflect.MakeMapWithSize(p.typ, v.Len()))
+		elem := reflect.New(p.typ.Elem()).Elem()
+		for it := v.MapRange(); it.Next(); {
+			elem.SetZero()
+			if err := p.elem.toShadow(elem, it.Value(), fmt.Sprintf("%s[%v]", path, it.Key()), encode); err != nil {
+				return err
+			}
+			sv.SetMapIndex(it.Key(), elem)
+		}
+	default:
+		for i, f := range p.fields {
+			if err := f.toShadow(sv.Field(i), v.Field(f.index), path+"."+f.name, encode); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// fromShadow copies the shadow value sv to v, at the given path.
+func (p *plan) fromShadow(v, sv reflect.Value, path string) error {
+	switch {
+	case p == nil:
+		v.Set(sv)
+	case p.id != nil:
+		if sv.IsNil() {
+			// Not set by the decoder.
+			return nil
+		}
+		t := sv.Interface().(*idText)
+		if t.notString != "" {
+			return &FieldError{Field: path, Err: &json.UnmarshalTypeError{Value: t.notString, Type: v.Type()}}
+		}
+		n, err := Uint64Detailed(t.text)
+		if err != nil {
+			return &FieldError{Field: path, Err: err}
+		}
+		var limit uint64 = math.MaxUint64
+		switch v.Kind() {
+		case reflect.Int64:
+			limit = math.MaxInt64
+		case reflect.Uint32:
+			limit = math.MaxUint32
+		}
+		if n > limit {
+			return &FieldError{Field: path, Err: DecodeError{Err: ErrOverflow}}
+		}
+		if v.Kind() == reflect.Int64 {
+			v.SetInt(int64(n))
+		} else {
+			v.SetUint(n)
+		}
+	case v.Kind() == reflect.Pointer:
+		if sv.IsNil() {
+			v.SetZero()
+			return nil
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return p.elem.fromShadow(v.Elem(), sv.Elem(), path)
+	case v.Kind() == reflect.Slice:
+		if sv.IsNil() {
+			v.SetZero()
+			return nil
+		}
+		v.Set(reflect.MakeSlice(v.Type(), sv.Len(), sv.Len()))
+		fallthrough
+	case v.Kind() == reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := p.elem.fromShadow(v.Index(i), sv.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case v.Kind() == reflect.Map:
+		if sv.IsNil() {
+			v.SetZero()
+			return nil
+		}
+		v.Set(reflect.MakeMapWithSize(v.Type(), sv.Len()))
+		elem := reflect.New(v.Type().Elem()).Elem()
+		for it := sv.MapRange(); it.Next(); {
+			elem.SetZero()
+			if err := p.elem.fromShadow(elem, it.Value(), fmt.Sprintf("%s[%v]", path, it.Key())); err != nil {
+				return err
+			}
+			v.SetMapIndex(it.Key(), elem)
+		}
+	default:
+		for i, f := range p.fields {
+			if err := f.fromShadow(v.Field(f.index), sv.Field(i), path+"."+f.name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
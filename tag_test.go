@@ -0,0 +1,175 @@
+package cford32
+
+import (
+	"encoding/xml"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type tagUserID uint64
+
+// TagBase is exported, as structs with tagged fields can't embed unexported
+// structs.
+type TagBase struct {
+	Org uint32 `json:"org" cford32:"full,upper"`
+}
+
+type tagUser struct {
+	TagBase
+	ID       tagUserID         `json:"id" cford32:"compact"`
+	Parent   *uint64           `json:"parent,omitempty" cford32:"compact"`
+	Friends  []int64           `json:"friends" cford32:"compact,upper"`
+	Optional uint64            `json:"optional,omitempty" cford32:"compact"`
+	Name     string            `json:"name"`
+	Raw      uint64            `json:"raw"`
+	Pets     []tagPet          `json:"pets,omitempty"`
+	ByName   map[string]tagPet `json:"by_name,omitempty"`
+	secret   uint64
+}
+
+type tagPet struct {
+	ID int64 `json:"id" cford32:"full"`
+}
+
+func TestMarshalJSON(t *testing.T) {
+	parent := uint64(1)
+	u := tagUser{
+		TagBase: TagBase{Org: 2},
+		ID:      16008560262,
+		Parent:  &parent,
+		Friends: []int64{3, 1 << 40},
+		Name:    "bob",
+		Raw:     4,
+		Pets:    []tagPet{{5}},
+		ByName:  map[string]tagPet{"rex": {6}},
+		secret:  7,
+	}
+	const want = `{"org":"G000000000002","id":"ex2yfm6","parent":"0000001","friends":["0000003","G000100000000"],` +
+		`"name":"bob","raw":4,"pets":[{"id":"g000000000005"}],"by_name":{"rex":{"id":"g000000000006"}}}`
+	b, err := MarshalJSON(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testEqual(t, "MarshalJSON(u) = %s, want %s", string(b), want)
+	b, err = MarshalJSON(&u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testEqual(t, "MarshalJSON(&u) = %s, want %s", string(b), want)
+
+	var got tagUser
+	if err := UnmarshalJSON([]byte(want), &got); err != nil {
+		t.Fatal(err)
+	}
+	u.secret = 0
+	if !reflect.DeepEqual(got, u) {
+		t.Errorf("UnmarshalJSON(%s) = %+v, want %+v", want, got, u)
+	}
+
+	// Fields missing from the input are left unchanged.
+	got = tagUser{ID: 1, Optional: 2, Raw: 3}
+	if err := UnmarshalJSON([]byte(`{"id": "EX2YFM6", "parent": null}`), &got); err != nil {
+		t.Fatal(err)
+	}
+	if want := (tagUser{ID: 16008560262, Optional: 2, Raw: 3}); !reflect.DeepEqual(got, want) {
+		t.Errorf("UnmarshalJSON = %+v, want %+v", got, want)
+	}
+}
+
+func TestMarshalJSONUntagged(t *testing.T) {
+	type plain struct {
+		A uint64 `json:"a"`
+	}
+	b, err := MarshalJSON(plain{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testEqual(t, "MarshalJSON(plain{1}) = %s, want %s", string(b), `{"a":1}`)
+	var p plain
+	if err := UnmarshalJSON(b, &p); err != nil {
+		t.Fatal(err)
+	}
+	testEqual(t, "UnmarshalJSON(%s) = %v, want %v", b, p, plain{1})
+}
+
+func TestTaggedErrors(t *testing.T) {
+	type small struct {
+		ID uint32 `cford32:"compact"`
+		N  int64  `cford32:"compact"`
+	}
+	type badType struct {
+		ID string `cford32:"compact"`
+	}
+	type badTag struct {
+		ID uint64 `cford32:"short"`
+	}
+	type node struct {
+		ID       uint64 `cford32:"compact"`
+		Children []node
+	}
+	tests := []struct {
+		name string
+		err  error
+		msg  string
+	}{
+		{"negative", func() error { _, err := MarshalJSON(small{N: -1}); return err }(),
+			"cford32: field small.N: negative value -1"},
+		{"invalid char", UnmarshalJSON([]byte(`{"ID": "00000!1"}`), new(small)),
+			"cford32: field small.ID: illegal cford32 data at input byte 5: invalid character '!'"},
+		{"uint32 overflow", UnmarshalJSON([]byte(`{"ID": "4000000"}`), new(small)),
+			"cford32: field small.ID: illegal cford32 data at input byte 0: value out of range"},
+		{"int64 overflow", UnmarshalJSON([]byte(`{"N": "ZZZZZZZZZZZZZ"}`), new(small)),
+			"cford32: field small.N: illegal cford32 data at input byte 0: value out of range"},
+		{"nested", UnmarshalJSON([]byte(`{"pets": [{}, {"id": "000"}]}`), new(tagUser)),
+			"cford32: field tagUser.Pets[1].ID: illegal cford32 data at input byte 0: invalid length"},
+		{"number", UnmarshalJSON([]byte(`{"ID": 1}`), new(small)),
+			"cford32: field small.ID: json: cannot unmarshal number into Go value of type uint32"},
+		{"object", UnmarshalJSON([]byte(`{"N": {}}`), new(small)),
+			"cford32: field small.N: json: cannot unmarshal object into Go value of type int64"},
+		{"bad type", func() error { _, err := MarshalJSON(badType{}); return err }(),
+			"cford32: field badType.ID: type string can't be tagged with cford32"},
+		{"bad tag", func() error { _, err := MarshalJSON(badTag{}); return err }(),
+			`cford32: field badTag.ID: invalid cford32 tag "short": must start with compact or full`},
+		{"recursive", func() error { _, err := MarshalJSON(node{}); return err }(),
+			"recursive type"},
+	}
+	for _, tc := range tests {
+		if tc.err == nil || !strings.Contains(tc.err.Error(), tc.msg) {
+			t.Errorf("%s: error = %v, want %q", tc.name, tc.err, tc.msg)
+		}
+	}
+	var ferr *FieldError
+	err := UnmarshalJSON([]byte(`{"ID": "4000000"}`), new(small))
+	if !errors.As(err, &ferr) || !errors.Is(err, ErrOverflow) {
+		t.Errorf("error = %#v, want a FieldError wrapping ErrOverflow", err)
+	}
+}
+
+func TestTaggedXML(t *testing.T) {
+	type item struct {
+		XMLName xml.Name `xml:"item"`
+		ID      uint64   `xml:"id,attr" cford32:"compact"`
+		Ref     uint64   `xml:"ref" cford32:"full"`
+	}
+	sv, err := Tagged(item{ID: 1, Ref: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := xml.Marshal(sv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `<item id="0000001"><ref>g000000000002</ref></item>`
+	testEqual(t, "xml.Marshal(Tagged(item)) = %s, want %s", string(b), want)
+
+	var got item
+	err = UnmarshalTagged(&got, func(sv any) error {
+		return xml.Unmarshal(b, sv)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testEqual(t, "UnmarshalTagged(%s) = %+v, want %+v", want, got, item{XMLName: xml.Name{Local: "item"}, ID: 1, Ref: 2})
+}
@@ -0,0 +1,115 @@
+package cford32
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// unixToInternal is the number of seconds between year 1 and 1970, which
+// bounds the Unix times that can be represented by a [time.Time].
+const unixToInternal = (1969*365 + 1969/4 - 1969/100 + 1969/400) * 24 * 60 * 60
+
+// PutTime returns the encoding of t, truncated to a multiple of precision
+// since the Unix epoch, using the full encoding of [PutUint64]. The encodings
+// of times with the same precision sort like the times.
+//
+// The precision must either divide a second, such as [time.Millisecond], or
+// be a multiple of a second, such as [time.Hour] to encode the hour of t; the
+// encodings of times within the same hour are then equal, which can be used to
+// build time-bucketed keys. The location and monotonic clock reading of t are
+// not encoded.
+//
+// An error is returned if precision is invalid, or if t can't be represented
+// as an int64 number of precision units since the Unix epoch: with
+// [time.Nanosecond], t must be within the years 1678 and 2262.
+func PutTime(t time.Time, precision time.Duration) ([13]byte, error) {
+	v, err := timeKey(t, precision)
+	if err != nil {
+		return [13]byte{}, err
+	}
+	return PutUint64(v), nil
+}
+
+// PutTimeLower is like [PutTime], but uses the lowercase variation of the
+// encoding.
+func PutTimeLower(t time.Time, precision time.Duration) ([13]byte, error) {
+	v, err := timeKey(t, precision)
+	if err != nil {
+		return [13]byte{}, err
+	}
+	return PutUint64Lower(v), nil
+}
+
+// checkPrecision returns an error if precision is not valid for [PutTime].
+func checkPrecision(precision time.Duration) error {
+	if precision <= 0 || (precision < time.Second && time.Second%precision != 0) ||
+		(precision > time.Second && precision%time.Second != 0) {
+		return fmt.Errorf("cford32: invalid time precision %v: must divide or be a multiple of a second", precision)
+	}
+	return nil
+}
+
+// timeKey returns the number of precision units between the Unix epoch and t,
+// with the sign bit flipped so that it sorts like t.
+func timeKey(t time.Time, precision time.Duration) (uint64, error) {
+	if err := checkPrecision(precision); err != nil {
+		return 0, err
+	}
+	sec := t.Unix()
+	var v int64
+	if precision >= time.Second {
+		v = floorDiv(sec, int64(precision/time.Second))
+	} else {
+		k := int64(time.Second / precision)
+		if sec > (math.MaxInt64-(k-1))/k || sec < math.MinInt64/k {
+			return 0, fmt.Errorf("cford32: time %v out of range with precision %v", t, precision)
+		}
+		v = sec*k + int64(t.Nanosecond())/int64(precision)
+	}
+	return uint64(v) ^ 1<<63, nil
+}
+
+// Time decodes a time encoded by [PutTime] with the given precision, and
+// returns it in UTC.
+//
+// Time is strict: b must be 13 characters long, using the full encoding, and
+// the aliases i I l L o O are not allowed. Inputs which fail these checks
+// return a [DecodeError] with reason [ErrInvalidChar], [ErrInvalidLength] or
+// [ErrNonCanonical], and values which can't be represented as a [time.Time]
+// with reason [ErrOverflow]. Both cases are accepted. An error which is not
+// a DecodeError is returned if precision is invalid.
+func Time(b []byte, precision time.Duration) (time.Time, error) {
+	if err := checkPrecision(precision); err != nil {
+		return time.Time{}, err
+	}
+	u, err := uint64Full(b)
+	if err != nil {
+		return time.Time{}, err
+	}
+	v := int64(u ^ 1<<63)
+	var sec, nsec int64
+	if precision >= time.Second {
+		k := int64(precision / time.Second)
+		if v > math.MaxInt64/k || v < math.MinInt64/k {
+			return time.Time{}, DecodeError{Offset: 0, Err: ErrOverflow}
+		}
+		sec = v * k
+	} else {
+		k := int64(time.Second / precision)
+		sec, nsec = floorDiv(v, k), (v-floorDiv(v, k)*k)*int64(precision)
+	}
+	if sec > math.MaxInt64-unixToInternal || sec < math.MinInt64+unixToInternal {
+		return time.Time{}, DecodeError{Offset: 0, Err: ErrOverflow}
+	}
+	return time.Unix(sec, nsec).UTC(), nil
+}
+
+// floorDiv returns a/b rounded towards negative infinity, for b > 0.
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b < 0 {
+		q--
+	}
+	return q
+}
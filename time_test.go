@@ -0,0 +1,91 @@
+package cford32
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTime(t *testing.T) {
+	loc := time.FixedZone("X", 3600)
+	times := []time.Time{
+		{},
+		time.Date(1600, 1, 1, 0, 0, 0, 999999999, time.UTC),
+		time.Date(1969, 12, 31, 23, 59, 59, 999999999, time.UTC),
+		time.Unix(0, 0),
+		time.Unix(0, 1),
+		time.Unix(0, 1e6),
+		time.Unix(1, 0),
+		time.Date(2024, 5, 6, 7, 8, 9, 123456789, loc),
+		time.Date(2024, 5, 6, 7, 8, 10, 0, time.UTC),
+		time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	for _, precision := range []time.Duration{time.Hour, time.Second, time.Millisecond, time.Microsecond, time.Nanosecond} {
+		var keys []string
+		for _, tm := range times {
+			enc, err := PutTime(tm, precision)
+			if precision == time.Nanosecond && (tm.Year() < 1678 || tm.Year() > 2262) {
+				if err == nil {
+					t.Errorf("PutTime(%v, %v): no error", tm, precision)
+				}
+				continue
+			}
+			if err != nil {
+				t.Errorf("PutTime(%v, %v): %v", tm, precision, err)
+				continue
+			}
+			keys = append(keys, string(enc[:]))
+			lower, _ := PutTimeLower(tm, precision)
+			testEqual(t, "PutTimeLower(%v, %v) = %s, want %s", tm, precision, string(lower[:]), strings.ToLower(string(enc[:])))
+
+			got, err := Time(lower[:], precision)
+			// Truncate rounds relative to the zero time, which is a whole
+			// number of hours before the Unix epoch.
+			want := tm.Truncate(precision)
+			if err != nil || !got.Equal(want) || got.Location() != time.UTC {
+				t.Errorf("Time(%s, %v) = %v, %v, want %v", lower, precision, got, err, want.UTC())
+			}
+		}
+		if !sort.StringsAreSorted(keys) {
+			t.Errorf("encodings with precision %v are not sorted: %q", precision, keys)
+		}
+	}
+
+	// Times within the same bucket have the same encoding.
+	a, _ := PutTime(time.Date(2024, 5, 6, 7, 0, 0, 0, time.UTC), time.Hour)
+	b, _ := PutTime(time.Date(2024, 5, 6, 7, 59, 59, 999, time.UTC), time.Hour)
+	testEqual(t, "PutTime(07:00) = %s, PutTime(07:59) = %s", string(a[:]), string(b[:]))
+}
+
+func TestTimeErrors(t *testing.T) {
+	for _, precision := range []time.Duration{0, -time.Second, 3 * time.Millisecond / 2, 1500 * time.Millisecond} {
+		if _, err := PutTime(time.Unix(0, 0), precision); err == nil {
+			t.Errorf("PutTime(0, %v): no error", precision)
+		}
+		if _, err := Time([]byte("G000000000000"), precision); err == nil {
+			t.Errorf("Time(0, %v): no error", precision)
+		}
+	}
+
+	tests := []struct {
+		src       string
+		precision time.Duration
+		offset    int64
+		err       error
+	}{
+		{"G00000", time.Second, 0, ErrInvalidLength},
+		{"G0000000000L0", time.Second, 11, ErrNonCanonical},
+		{"ZZZZZZZZZZZZZ", time.Second, 0, ErrOverflow},
+		{"ZZZZZZZZZZZZZ", time.Hour, 0, ErrOverflow},
+		{"G000000000000", time.Hour, 0, ErrOverflow},
+	}
+	for _, tc := range tests {
+		_, err := Time([]byte(tc.src), tc.precision)
+		var derr DecodeError
+		if !errors.As(err, &derr) || derr.Offset != tc.offset || !errors.Is(err, tc.err) {
+			t.Errorf("Time(%q, %v) = %v, want error at %d: %v", tc.src, tc.precision, err, tc.offset, tc.err)
+		}
+	}
+}
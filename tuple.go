@@ -0,0 +1,213 @@
+package cford32
+
+import (
+	"fmt"
+	"time"
+)
+
+// Tuple encoding
+//
+// Each field of a tuple is encoded as a symbol identifying its type, followed
+// by the encoding of its value, which sorts like the values of its type:
+// integers use the full encoding of [PutUint64], with the sign bit flipped for
+// int64s; byte strings and strings use the ordered encoding of
+// [AppendOrdered]; times use the seconds since the Unix epoch, as an int64,
+// followed by the nanoseconds within the second, as 6 symbols.
+
+// Symbols identifying the type of the fields of a tuple.
+const (
+	tupleUint64 = 1
+	tupleInt64  = 2
+	tupleBytes  = 3
+	tupleString = 4
+	tupleTime   = 5
+)
+
+const (
+	tupleIntLen  = 13
+	tupleNsecLen = 6
+)
+
+// AppendTuple appends the encoding of the tuple of fields to dst, and returns
+// the extended buffer. The fields may be of type uint64, int64, []byte, string
+// and [time.Time]; an error is returned for other types.
+//
+// The encodings of tuples of the same field types sort like the tuples, as
+// long as they all use the same case: the tuples are compared field by field,
+// and times are compared like with [time.Time.Compare]. As the encoding of a
+// tuple is the concatenation of the encodings of its fields, the encoding of
+// the first fields of a tuple is a prefix of the encoding of the tuple, which
+// can be used for prefix scans in sorted stores, such as listing all the keys
+// of a tenant.
+//
+// Times are encoded with nanosecond precision; their location and monotonic
+// clock reading are not encoded.
+func AppendTuple(dst []byte, fields ...any) ([]byte, error) {
+	return appendTuple(dst, fields, encTable)
+}
+
+// AppendTupleLower is like [AppendTuple], but uses the lowercase variation of
+// the encoding.
+func AppendTupleLower(dst []byte, fields ...any) ([]byte, error) {
+	return appendTuple(dst, fields, encTableLower)
+}
+
+func appendTuple(dst []byte, fields []any, table string) ([]byte, error) {
+	for i, f := range fields {
+		switch f := f.(type) {
+		case uint64:
+			dst = appendFull(append(dst, table[tupleUint64]), f, table)
+		case int64:
+			dst = appendFull(append(dst, table[tupleInt64]), uint64(f)^1<<63, table)
+		case []byte:
+			dst = appendOrdered(append(dst, table[tupleBytes]), f, table)
+		case string:
+			dst = appendOrdered(append(dst, table[tupleString]), []byte(f), table)
+		case time.Time:
+			dst = append(dst, table[tupleTime])
+			dst = appendFull(dst, uint64(f.Unix())^1<<63, table)
+			ns := f.Nanosecond()
+			for shift := 5 * (tupleNsecLen - 1); shift >= 0; shift -= 5 {
+				dst = append(dst, table[ns>>shift&mask])
+			}
+		default:
+			return dst, fmt.Errorf("cford32: tuple field %d has unsupported type %T", i, f)
+		}
+	}
+	return dst, nil
+}
+
+// appendFull appends the full encoding of v, as [PutUint64], to dst.
+func appendFull(dst []byte, v uint64, table string) []byte {
+	dst = append(dst, table[v>>60&mask|0x10])
+	for shift := 55; shift >= 0; shift -= 5 {
+		dst = append(dst, table[v>>shift&mask])
+	}
+	return dst
+}
+
+// DecodeTuple decodes the encoding of a tuple, as produced by [AppendTuple],
+// and returns its fields, of type uint64, int64, []byte, string and
+// [time.Time]. Times are returned in UTC.
+//
+// Both cases are accepted, with the aliases accepted by [Decode]. If src is
+// not the encoding of a tuple, a [DecodeError] is returned, with the offset of
+// the invalid input in src.
+func DecodeTuple(src []byte) ([]any, error) {
+	var fields []any
+	for i := 0; i < len(src); {
+		v, n, err := decodeTupleField(src[i:])
+		if err != nil {
+			return nil, shiftError(err, i)
+		}
+		fields = append(fields, v)
+		i += n
+	}
+	return fields, nil
+}
+
+// decodeTupleField decodes the field at the start of src, returning its value
+// and the length of its encoding.
+func decodeTupleField(src []byte) (any, int, error) {
+	switch tag := decTable[src[0]]; tag {
+	case tupleUint64, tupleInt64:
+		v, err := decodeFull(src[1:])
+		if err != nil {
+			return nil, 0, shiftError(err, 1)
+		}
+		if tag == tupleInt64 {
+			return int64(v ^ 1<<63), 1 + tupleIntLen, nil
+		}
+		return v, 1 + tupleIntLen, nil
+	case tupleBytes, tupleString:
+		b, n, err := DecodeOrdered(nil, src[1:])
+		if err != nil {
+			return nil, 0, shiftError(err, 1)
+		}
+		if tag == tupleString {
+			return string(b), 1 + n, nil
+		}
+		if b == nil {
+			b = []byte{}
+		}
+		return b, 1 + n, nil
+	case tupleTime:
+		sec, err := decodeFull(src[1:])
+		if err != nil {
+			return nil, 0, shiftError(err, 1)
+		}
+		const off = 1 + tupleIntLen
+		if len(src) < off+tupleNsecLen {
+			return nil, 0, DecodeError{Offset: int64(len(src)), Err: ErrInvalidLength}
+		}
+		var ns int64
+		for i, c := range src[off : off+tupleNsecLen] {
+			v := decTable[c]
+			if v >= 32 {
+				return nil, 0, DecodeError{Offset: int64(off + i), Char: c, Err: ErrInvalidChar}
+			}
+			ns = ns<<5 | int64(v)
+		}
+		if ns >= 1e9 {
+			return nil, 0, DecodeError{Offset: off, Err: ErrOverflow}
+		}
+		return time.Unix(int64(sec^1<<63), ns).UTC(), off + tupleNsecLen, nil
+	}
+	return nil, 0, DecodeError{Offset: 0, Char: src[0], Err: ErrInvalidChar}
+}
+
+// decodeFull decodes the full encoding of a uint64 at the start of src.
+func decodeFull(src []byte) (uint64, error) {
+	if len(src) < tupleIntLen {
+		return 0, DecodeError{Offset: int64(len(src)), Err: ErrInvalidLength}
+	}
+	if decTable[src[0]] < 16 {
+		// A compact encoding, which is not used in tuples.
+		return 0, DecodeError{Offset: 0, Char: src[0], Err: ErrNonCanonical}
+	}
+	return Uint64Detailed(src[:tupleIntLen])
+}
+
+// shiftError adds n to the offset of err, if it is a [DecodeError].
+func shiftError(err error, n int) error {
+	if derr, ok := err.(DecodeError); ok {
+		derr.Offset += int64(n)
+		return derr
+	}
+	return err
+}
+
+// ScanTuple decodes the encoding of a tuple, as [DecodeTuple], and stores its
+// fields in the values pointed to by dst, which must be of type *uint64,
+// *int64, *[]byte, *string, *[time.Time] or *any, and match the types and the
+// number of the fields.
+func ScanTuple(src []byte, dst ...any) error {
+	fields, err := DecodeTuple(src)
+	if err != nil {
+		return err
+	}
+	if len(fields) != len(dst) {
+		return fmt.Errorf("cford32: tuple has %d fields, scanning into %d values", len(fields), len(dst))
+	}
+	for i, f := range fields {
+		ok := false
+		switch d := dst[i].(type) {
+		case *uint64:
+			*d, ok = f.(uint64)
+		case *int64:
+			*d, ok = f.(int64)
+		case *[]byte:
+			*d, ok = f.([]byte)
+		case *string:
+			*d, ok = f.(string)
+		case *time.Time:
+			*d, ok = f.(time.Time)
+		case *any:
+			*d, ok = f, true
+		}
+		if !ok {
+			return fmt.Errorf("cford32: can't scan tuple field %d of type %T into %T", i, f, dst[i])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package cford32
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTuple(t *testing.T) {
+	now := time.Date(2024, 5, 6, 7, 8, 9, 123456789, time.UTC)
+	fields := []any{uint64(42), int64(-1), []byte{0, 0xff}, "tenant", now}
+	enc, err := AppendTuple(nil, fields...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := DecodeTuple(enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, fields) {
+		t.Errorf("DecodeTuple(%s) = %v, want %v", enc, got, fields)
+	}
+	lower, _ := AppendTupleLower(nil, fields...)
+	testEqual(t, "AppendTupleLower = %s, want %s", string(lower), strings.ToLower(string(enc)))
+	if got, err := DecodeTuple(lower); err != nil || !reflect.DeepEqual(got, fields) {
+		t.Errorf("DecodeTuple(%s) = %v, %v, want %v", lower, got, err, fields)
+	}
+
+	var (
+		u  uint64
+		i  int64
+		b  []byte
+		s  string
+		tm time.Time
+		v  any
+	)
+	if err := ScanTuple(enc, &u, &i, &b, &s, &v); err != nil {
+		t.Fatal(err)
+	}
+	if u != 42 || i != -1 || !bytes.Equal(b, []byte{0, 0xff}) || s != "tenant" || v != now {
+		t.Errorf("ScanTuple = %v %v %v %v %v", u, i, b, s, v)
+	}
+	if err := ScanTuple(enc, &u, &i, &b, &s); err == nil {
+		t.Errorf("ScanTuple with too few values: no error")
+	}
+	if err := ScanTuple(enc, &u, &i, &b, &tm, &tm); err == nil {
+		t.Errorf("ScanTuple with mismatched types: no error")
+	}
+
+	if _, err := AppendTuple(nil, 1); err == nil {
+		t.Errorf("AppendTuple(1): no error")
+	}
+}
+
+func TestTupleSort(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	type tuple struct {
+		tenant string
+		at     time.Time
+		n      int64
+		id     uint64
+	}
+	tuples := []tuple{
+		{"", base, 0, 0},
+		{"a", base, 0, 0},
+		{"a", base.Add(1), 0, 0},
+		{"a", base.Add(time.Second), math.MinInt64, 0},
+		{"a", base.Add(time.Second), -1, 0},
+		{"a", base.Add(time.Second), 0, 0},
+		{"a", base.Add(time.Second), 0, 1},
+		{"a", base.Add(time.Second), 0, math.MaxUint64},
+		{"a", base.Add(time.Second), math.MaxInt64, 0},
+		{"a\x00", time.Time{}, 0, 0},
+		{"ab", time.Date(1900, 1, 1, 0, 0, 0, 5, time.UTC), 0, 0},
+		{"ab", time.Date(1900, 1, 1, 0, 0, 1, 0, time.UTC), 0, 0},
+		{"b", base, 0, 0},
+	}
+	keys := make([]string, len(tuples))
+	for i, tp := range tuples {
+		k, err := AppendTuple(nil, tp.tenant, tp.at, tp.n, tp.id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[i] = string(k)
+	}
+	if !sort.StringsAreSorted(keys) {
+		t.Errorf("tuple encodings are not sorted: %q", keys)
+	}
+
+	// The encoding of the first fields is a prefix of the encoding of the tuple.
+	prefix, _ := AppendTuple(nil, "a")
+	for i, k := range keys {
+		want := tuples[i].tenant == "a"
+		if got := strings.HasPrefix(k, string(prefix)); got != want {
+			t.Errorf("HasPrefix(%s, %s) = %t, want %t", k, prefix, got, want)
+		}
+	}
+}
+
+func TestDecodeTupleErrors(t *testing.T) {
+	tests := []struct {
+		src    string
+		offset int64
+		err    error
+	}{
+		{"9", 0, ErrInvalidChar},
+		{"1G00", 4, ErrInvalidLength},
+		{"10000000000001", 1, ErrNonCanonical},
+		{"1G0000000000001G000000000000!", 28, ErrInvalidChar},
+		{"341", 3, ErrInvalidLength},
+		{"441!", 3, ErrInvalidChar},
+		{"5G000000000000ZZZZ", 18, ErrInvalidLength},
+		{"5G000000000000ZZZZZZ", 14, ErrOverflow},
+	}
+	for _, tc := range tests {
+		_, err := DecodeTuple([]byte(tc.src))
+		var derr DecodeError
+		if !errors.As(err, &derr) || derr.Offset != tc.offset || !errors.Is(err, tc.err) {
+			t.Errorf("DecodeTuple(%q) = %v, want error at %d: %v", tc.src, err, tc.offset, tc.err)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package cford32
+
+// isAlias reports whether c is one of the characters which are accepted when
+// decoding as an alias for another one, but never produced by the encoder:
+// i I l L, parsed as 1, and o O, parsed as 0.
+func isAlias(c byte) bool {
+	switch c {
+	case 'i', 'I', 'l', 'L', 'o', 'O':
+		return true
+	}
+	return false
+}
+
+// unusedBits is the number of unused low bits in the last character of a
+// final quantum of the given length. Quanta of 1, 3 and 6 characters are
+// never produced by the encoder.
+var unusedBits = [8]byte{0, 0, 2, 0, 4, 1, 0, 3}
+
+// Validate reports whether src is valid cford32-encoded data, without decoding
+// it. It returns nil if [Decode] would succeed on src, and otherwise the same
+// [DecodeError] as [DecodeDetailed]. Validate never allocates.
+func Validate(src []byte) error {
+	for i, c := range src {
+		if decTable[c] == 0xFF && !isNewline(c) {
+			return DecodeError{Offset: int64(i), Char: c, Err: ErrInvalidChar}
+		}
+	}
+	return nil
+}
+
+// ValidateStrict is like [Validate], but it also requires src to be in the
+// canonical form produced by the encoder. Inputs which fail these checks
+// return a [DecodeError] with reason [ErrInvalidLength] or [ErrNonCanonical]:
+//
+//   - The aliases i I l L o O are not allowed.
+//   - The last quantum must have a length that can be produced by the encoder:
+//     1, 3 and 6 characters, which are ignored by Decode, are not allowed.
+//   - The unused low bits of the last character of the last quantum must be
+//     zero, so that there is a single encoding for any data.
+//
+// Newline characters are still allowed. Like Validate, ValidateStrict never
+// allocates.
+func ValidateStrict(src []byte) error {
+	chars, last := 0, -1
+	for i, c := range src {
+		if isNewline(c) {
+			continue
+		}
+		if decTable[c] == 0xFF {
+			return DecodeError{Offset: int64(i), Char: c, Err: ErrInvalidChar}
+		}
+		if isAlias(c) {
+			return DecodeError{Offset: int64(i), Char: c, Err: ErrNonCanonical}
+		}
+		chars++
+		last = i
+	}
+	switch chars % 8 {
+	case 0:
+	case 1, 3, 6:
+		return DecodeError{Offset: int64(last), Err: ErrInvalidLength}
+	default:
+		if decTable[src[last]]&(1<<unusedBits[chars%8]-1) != 0 {
+			return DecodeError{Offset: int64(last), Char: src[last], Err: ErrNonCanonical}
+		}
+	}
+	return nil
+}
+
+// ValidateUint64 reports whether b is a valid encoded uint64, without
+// decoding it. It returns nil if [Uint64] would succeed on b, and otherwise
+// the same [DecodeError] as [Uint64Detailed].
+func ValidateUint64(b []byte) error {
+	_, err := Uint64Detailed(b)
+	return err
+}
+
+// ValidateUint64Strict is like [ValidateUint64], but it also requires b to
+// be in the canonical form produced by the encoding functions: the aliases
+// i I l L o O are not allowed, and return a [DecodeError] with reason
+// [ErrNonCanonical].
+//
+// Both the compact and full encodings are canonical, as either may be
+// produced for values in [0,2^34) depending on the function used.
+func ValidateUint64Strict(b []byte) error {
+	if _, err := Uint64Detailed(b); err != nil {
+		return err
+	}
+	for i, c := range b {
+		if isAlias(c) {
+			return DecodeError{Offset: int64(i), Char: c, Err: ErrNonCanonical}
+		}
+	}
+	return nil
+}
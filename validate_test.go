@@ -0,0 +1,96 @@
+package cford32
+
+import (
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	inputs := []string{
+		"", "iIoOlL", "!!!!", "uxp10", "x===", "AA=A====", "AAA=AAAA",
+		"AA\nA-=AAAA", "D1JP-RV3F\r\nEXQQ4V34", "D1JPRV3F\r\nEXQQ4V34", "D1JPRV3F\nEXQQ!",
+		"csqpyrk1u",
+	}
+	for _, p := range append(pairs, bigtest) {
+		inputs = append(inputs, p.encoded)
+	}
+	for _, input := range inputs {
+		_, want := DecodeDetailed(make([]byte, DecodedLen(len(input))), []byte(input))
+		got := Validate([]byte(input))
+		testEqual(t, "Validate(%q) = %v, want %v", input, got, want)
+	}
+}
+
+func TestValidateStrict(t *testing.T) {
+	for _, p := range append(pairs, bigtest) {
+		err := ValidateStrict([]byte(p.encoded))
+		testEqual(t, "ValidateStrict(%q) = %v, want %v", p.encoded, err, error(nil))
+	}
+
+	testCases := []struct {
+		input string
+		err   error
+	}{
+		{"", nil},
+		{"\n\r", nil},
+		{"D1JPRV3F\r\nexqq4v34", nil},
+		{"D1JP-RV3F", DecodeError{Offset: 4, Char: '-', Err: ErrInvalidChar}},
+		{"D1JP!", DecodeError{Offset: 4, Char: '!', Err: ErrInvalidChar}},
+		{"D1JPRV3F\nEXQQ!", DecodeError{Offset: 13, Char: '!', Err: ErrInvalidChar}},
+		{"iIoOlL", DecodeError{Offset: 0, Char: 'i', Err: ErrNonCanonical}},
+		{"D1JPRV3FEXQQ4V3L", DecodeError{Offset: 15, Char: 'L', Err: ErrNonCanonical}},
+		{"C", DecodeError{Offset: 0, Err: ErrInvalidLength}},
+		{"CSQ\n", DecodeError{Offset: 2, Err: ErrInvalidLength}},
+		{"CSQPYR", DecodeError{Offset: 5, Err: ErrInvalidLength}},
+		{"CSQPYRK1E", DecodeError{Offset: 8, Err: ErrInvalidLength}},
+		// Non-zero unused bits.
+		{"CS", DecodeError{Offset: 1, Char: 'S', Err: ErrNonCanonical}},
+		{"CSQH", DecodeError{Offset: 3, Char: 'H', Err: ErrNonCanonical}},
+		{"CSQPZ", DecodeError{Offset: 4, Char: 'Z', Err: ErrNonCanonical}},
+		{"CSQPYRH", DecodeError{Offset: 6, Char: 'H', Err: ErrNonCanonical}},
+		{"CSQPYRK1E9\n", DecodeError{Offset: 9, Char: '9', Err: ErrNonCanonical}},
+	}
+	for _, tc := range testCases {
+		err := ValidateStrict([]byte(tc.input))
+		testEqual(t, "ValidateStrict(%q) = %v, want %v", tc.input, err, tc.err)
+	}
+}
+
+func TestValidateUint64(t *testing.T) {
+	for _, input := range []string{
+		"0000001", "OoOoOoL", "OoUoOoL", "!123123", "goooooo", "g00000fzzzzzz", "g000000", "",
+	} {
+		_, want := Uint64Detailed([]byte(input))
+		got := ValidateUint64([]byte(input))
+		testEqual(t, "ValidateUint64(%q) = %v, want %v", input, got, want)
+	}
+
+	testCases := []struct {
+		input string
+		err   error
+	}{
+		{"0000001", nil},
+		{"ex2yfm6", nil},
+		{"EX2YFM6", nil},
+		{"g00000fzzzzzz", nil},
+		{"OoOoOoL", DecodeError{Offset: 0, Char: 'O', Err: ErrNonCanonical}},
+		{"00000o1", DecodeError{Offset: 5, Char: 'o', Err: ErrNonCanonical}},
+		{"g0000000000i0", DecodeError{Offset: 11, Char: 'i', Err: ErrNonCanonical}},
+		{"OoUoOoL", DecodeError{Offset: 2, Char: 'U', Err: ErrInvalidChar}},
+	}
+	for _, tc := range testCases {
+		err := ValidateUint64Strict([]byte(tc.input))
+		testEqual(t, "ValidateUint64Strict(%q) = %v, want %v", tc.input, err, tc.err)
+	}
+}
+
+func TestValidateAllocs(t *testing.T) {
+	src := []byte("D1JPRV3F\nEXQQ\r\n4V34")
+	id := []byte("ex2yfm6")
+	allocs := testing.AllocsPerRun(100, func() {
+		Validate(src)
+		ValidateStrict(src)
+		ValidateUint64(id)
+		ValidateUint64Strict(id)
+	})
+	testEqual(t, "Validate allocations = %v, want %v", allocs, float64(0))
+}